@@ -2,9 +2,17 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"fuzzer/internal/api"
+	"fuzzer/internal/audit"
+	"fuzzer/internal/auth"
+	"fuzzer/internal/config"
 	"fuzzer/internal/fuzzer"
 	"fuzzer/internal/logging"
 	"fuzzer/internal/storage"
@@ -12,32 +20,88 @@ import (
 )
 
 func main() {
-	// Initialize logging (you can pass LevelDebug for more verbose logging)
+	if len(os.Args) > 1 && os.Args[1] == "token" {
+		if err := runTokenCommand(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Start with info logging so config errors are reported, then switch to
+	// the configured level once the config has been loaded
 	logging.InitLogger(logging.LevelInfo)
 
-	ctx := context.Background()
+	cfg, err := config.Load(os.Args[1:], os.LookupEnv)
+	if err != nil {
+		logging.Error("Failed to load configuration: %v", err)
+		os.Exit(2)
+	}
+	logging.InitLogger(cfg.LogLevel())
+
+	// ctx is cancelled on SIGINT or SIGTERM to start a graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	store, err := storage.NewJobStore("jobs.json")
+	store, err := storage.NewJobStore(cfg.Storage.JobsFile)
 	if err != nil {
 		logging.Error("Failed to initialize job store: %v", err)
 		return
 	}
 	logging.Info("Job store initialized successfully")
 
-	wordlistMgr, err := wordlist.NewManager("wordlists")
+	wordlistMgr, err := wordlist.NewManager(cfg.Storage.WordlistDir)
 	if err != nil {
 		logging.Error("Failed to initialize wordlist manager: %v", err)
 		return
 	}
+	if err := wordlistMgr.RegisterBuiltins(); err != nil {
+		logging.Error("Failed to register builtin wordlists: %v", err)
+		return
+	}
+	// Closed after the fuzzer manager has shut down, so the words its last
+	// findings taught are saved
+	defer wordlistMgr.Close()
 	logging.Info("Wordlist manager initialized successfully")
 
-	manager := fuzzer.NewManager(ctx, store, wordlistMgr, 10.0)
+	// The manager gets its own context so running jobs are cancelled by
+	// Shutdown after new work has been refused, not by the signal itself
+	manager := fuzzer.NewManager(context.Background(), store, wordlistMgr, cfg.Fuzzer.RateLimit)
+	manager.SetJobDefaults(cfg.JobOptions())
+	serverScope, projectScopes, err := cfg.Scopes()
+	if err != nil {
+		logging.Error("Failed to build target scope: %v", err)
+		return
+	}
+	manager.SetScope(serverScope, projectScopes)
 	logging.Info("Fuzzer manager initialized")
 
+	auditLog, err := audit.Open(cfg.Audit.File)
+	if err != nil {
+		logging.Error("Failed to open audit log: %v", err)
+		return
+	}
+	defer auditLog.Close()
+	manager.SetAuditor(auditLog)
+
 	apiHandler := api.NewHandler(manager, wordlistMgr, store)
+	apiHandler.SetAuditLog(auditLog)
+	if cfg.Auth.Enabled {
+		tokens, err := auth.NewTokenStore(cfg.Auth.TokensFile)
+		if err != nil {
+			logging.Error("Failed to initialize token store: %v", err)
+			return
+		}
+		if len(tokens.List()) == 0 {
+			logging.Info("No API tokens exist yet; create one with: http-fuzzer token create -name admin -role admin")
+		}
+		apiHandler.RequireAuth(tokens)
+	} else {
+		logging.Info("WARNING: API authentication is disabled, anyone who can reach %s can start scans", cfg.Server.Addr)
+	}
 
 	// Serve static files for UI
-	fs := http.FileServer(http.Dir("./web/static"))
+	fs := http.FileServer(http.Dir(cfg.Server.StaticDir))
 	http.Handle("/", fs)
 	logging.Info("Static file server initialized")
 
@@ -45,8 +109,33 @@ func main() {
 	http.Handle("/api/", apiHandler)
 	logging.Info("API routes registered")
 
-	logging.Info("Starting server on :8080")
-	if err := http.ListenAndServe(":8080", nil); err != nil {
+	server := &http.Server{Addr: cfg.Server.Addr}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		logging.Info("Starting server on %s", cfg.Server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
 		logging.Error("Server failed to start: %v", err)
+		return
+	case <-ctx.Done():
+		stop()
+	}
+
+	logging.Info("Shutdown signal received, allowing up to %s to finish", cfg.Server.ShutdownGrace)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownGrace)
+	defer cancel()
+
+	// Stop jobs first so no new scans start while HTTP requests drain
+	if err := manager.Shutdown(shutdownCtx); err != nil {
+		logging.Error("Fuzzer manager did not shut down cleanly: %v", err)
+	}
+
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logging.Error("HTTP server did not shut down cleanly: %v", err)
 	}
+	logging.Info("Server stopped")
 }
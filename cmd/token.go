@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"fuzzer/internal/auth"
+	"fuzzer/internal/config"
+)
+
+const tokenUsage = `Usage:
+  http-fuzzer token create -name NAME -role viewer|operator|admin
+  http-fuzzer token list
+  http-fuzzer token revoke -id ID
+
+Every subcommand also accepts -config FILE and -tokens-file FILE.`
+
+// runTokenCommand implements the "token" subcommand used to manage API tokens
+func runTokenCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New(tokenUsage)
+	}
+
+	fs := flag.NewFlagSet("token "+args[0], flag.ContinueOnError)
+	configPath := fs.String("config", "", "path to a YAML config file")
+	tokensFile := fs.String("tokens-file", "", "file used to store API token hashes")
+	name := fs.String("name", "", "name of the token owner")
+	roleName := fs.String("role", "", "role granted to the token")
+	id := fs.String("id", "", "ID of the token to revoke")
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	path := *tokensFile
+	if path == "" {
+		var configArgs []string
+		if *configPath != "" {
+			configArgs = []string{"-config", *configPath}
+		}
+		cfg, err := config.Load(configArgs, os.LookupEnv)
+		if err != nil {
+			return err
+		}
+		path = cfg.Auth.TokensFile
+	}
+
+	store, err := auth.NewTokenStore(path)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "create":
+		if *name == "" {
+			return errors.New("token create: -name is required")
+		}
+		role, err := auth.ParseRole(*roleName)
+		if err != nil {
+			return fmt.Errorf("token create: %w", err)
+		}
+		secret, token, err := store.Create(*name, role)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created %s token %s for %s.\n", token.Role, token.ID, token.Name)
+		fmt.Println("Store it now, it will not be shown again:")
+		fmt.Println(secret)
+
+	case "list":
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tROLE\tCREATED")
+		for _, token := range store.List() {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", token.ID, token.Name, token.Role, token.Created.Format("2006-01-02 15:04"))
+		}
+		w.Flush()
+
+	case "revoke":
+		if *id == "" {
+			return errors.New("token revoke: -id is required")
+		}
+		if err := store.Revoke(*id); err != nil {
+			return fmt.Errorf("token revoke: %w", err)
+		}
+		fmt.Printf("Revoked token %s.\n", *id)
+
+	default:
+		return fmt.Errorf("unknown token command %q\n\n%s", args[0], tokenUsage)
+	}
+
+	return nil
+}
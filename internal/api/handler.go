@@ -3,9 +3,20 @@ package api
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"fuzzer/internal/audit"
+	"fuzzer/internal/auth"
 	"fuzzer/internal/logging"
+	"fuzzer/internal/scope"
 	"fuzzer/internal/storage"
 	"fuzzer/internal/wordlist"
 	"fuzzer/types"
@@ -15,38 +26,126 @@ type Handler struct {
 	fuzzerMgr   types.FuzzerManager
 	wordlistMgr *wordlist.Manager
 	store       *storage.JobStore
+	auth        auth.Authenticator
+	audit       *audit.Log
+	routes      map[string]route
+}
+
+// route pairs an API handler with the minimum role allowed to call it
+type route struct {
+	role    auth.Role
+	handler http.HandlerFunc
 }
 
 func NewHandler(f types.FuzzerManager, w *wordlist.Manager, s *storage.JobStore) *Handler {
 	logging.Info("Creating new API handler")
-	return &Handler{
+	h := &Handler{
 		fuzzerMgr:   f,
 		wordlistMgr: w,
 		store:       s,
 	}
+	h.routes = map[string]route{
+		"/api/jobs":               {auth.RoleViewer, h.handleJobs},
+		"/api/jobs/start":         {auth.RoleOperator, h.handleStartJob},
+		"/api/jobs/stop":          {auth.RoleOperator, h.handleStopJob},
+		"/api/jobs/delete":        {auth.RoleOperator, h.handleDeleteJob},
+		"/api/wordlists":          {auth.RoleViewer, h.handleWordlists},
+		"/api/wordlists/add":      {auth.RoleOperator, h.handleAddWordlist},
+		"/api/wordlists/derive":   {auth.RoleOperator, h.handleDeriveWordlist},
+		"/api/wordlists/delete":   {auth.RoleOperator, h.handleDeleteWordlist},
+		"/api/wordlists/rename":   {auth.RoleOperator, h.handleRenameWordlist},
+		"/api/wordlists/describe": {auth.RoleOperator, h.handleDescribeWordlist},
+		"/api/wordlists/replace":  {auth.RoleOperator, h.handleReplaceWordlist},
+		"/api/wordlists/append":   {auth.RoleOperator, h.handleAppendWordlist},
+		"/api/wordlists/download": {auth.RoleViewer, h.handleDownloadWordlist},
+		"/api/rate-limit":         {auth.RoleAdmin, h.handleUpdateRateLimit},
+		"/api/auth/whoami":        {auth.RoleViewer, h.handleWhoami},
+		"/api/audit":              {auth.RoleAdmin, h.handleAudit},
+		"/api/audit/verify":       {auth.RoleAdmin, h.handleAuditVerify},
+	}
+	return h
+}
+
+// RequireAuth makes every route require a bearer token whose role allows it.
+// Without it the API is open to anyone who can reach the server.
+func (h *Handler) RequireAuth(a auth.Authenticator) {
+	logging.Info("API token authentication enabled")
+	h.auth = a
+}
+
+// SetAuditLog records job and wordlist actions to the given audit log
+func (h *Handler) SetAuditLog(l *audit.Log) {
+	h.audit = l
+}
+
+// record writes an audit entry for the authenticated caller. Failing to
+// audit doesn't undo the action, but it is logged as an error.
+func (h *Handler) record(r *http.Request, action string, params map[string]string) {
+	if h.audit == nil {
+		return
+	}
+	if err := h.audit.Record(actorName(r), action, params); err != nil {
+		logging.Error("Failed to record audit entry %s: %v", action, err)
+	}
 }
 
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	logging.Info("Received request: %s %s", r.Method, r.URL.Path)
-	switch r.URL.Path {
-	case "/api/jobs":
-		h.handleJobs(w, r)
-	case "/api/jobs/start":
-		h.handleStartJob(w, r)
-	case "/api/jobs/stop":
-		h.handleStopJob(w, r)
-	case "/api/jobs/delete":
-		h.handleDeleteJob(w, r)
-	case "/api/wordlists":
-		h.handleWordlists(w, r)
-	case "/api/wordlists/add":
-		h.handleAddWordlist(w, r)
-	case "/api/rate-limit":
-		h.handleUpdateRateLimit(w, r)
-	default:
+
+	rt, exists := h.routes[r.URL.Path]
+	if !exists {
 		logging.Error("Not found: %s", r.URL.Path)
 		http.NotFound(w, r)
+		return
 	}
+
+	if h.auth != nil {
+		token, err := h.authenticate(r)
+		if err != nil {
+			logging.Error("Unauthorized request: %s %s: %v", r.Method, r.URL.Path, err)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="http-fuzzer"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if !token.Role.Allows(rt.role) {
+			logging.Error("Forbidden request: %s %s by %s (role %s, requires %s)",
+				r.Method, r.URL.Path, token.Name, token.Role, rt.role)
+			http.Error(w, fmt.Sprintf("role %q may not call %s", token.Role, r.URL.Path), http.StatusForbidden)
+			return
+		}
+		r = r.WithContext(auth.WithToken(r.Context(), token))
+	}
+
+	rt.handler(w, r)
+}
+
+// authenticate checks the request's "Authorization: Bearer" token
+func (h *Handler) authenticate(r *http.Request) (*auth.Token, error) {
+	header := r.Header.Get("Authorization")
+	secret, found := strings.CutPrefix(header, "Bearer ")
+	if !found || secret == "" {
+		return nil, errors.New("missing bearer token")
+	}
+	return h.auth.Authenticate(strings.TrimSpace(secret))
+}
+
+// actorName returns the name of the authenticated caller
+func actorName(r *http.Request) string {
+	if token := auth.FromContext(r.Context()); token != nil {
+		return token.Name
+	}
+	return "anonymous"
+}
+
+func (h *Handler) handleWhoami(w http.ResponseWriter, r *http.Request) {
+	resp := map[string]string{"name": "anonymous", "role": string(auth.RoleAdmin)}
+	if token := auth.FromContext(r.Context()); token != nil {
+		resp["name"] = token.Name
+		resp["role"] = string(token.Role)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
 }
 
 func (h *Handler) handleDeleteJob(w http.ResponseWriter, r *http.Request) {
@@ -62,11 +161,13 @@ func (h *Handler) handleDeleteJob(w http.ResponseWriter, r *http.Request) {
 
 	logging.Info("Deleting job: JobID=%s", req.JobID)
 
+	target := h.jobTarget(req.JobID)
 	if err := h.fuzzerMgr.DeleteJob(req.JobID); err != nil {
 		logging.Error("Failed to delete job: %v", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	h.record(r, "job.delete", map[string]string{"jobId": req.JobID, "target": target})
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -93,9 +194,10 @@ func (h *Handler) handleJobs(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) handleStartJob(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Target     string        `json:"target"`
-		WordlistID string        `json:"wordlistId"`
-		Type       types.JobType `json:"type"`
+		Target     string           `json:"target"`
+		WordlistID string           `json:"wordlistId"`
+		Type       types.JobType    `json:"type"`
+		Options    types.JobOptions `json:"options"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -104,27 +206,49 @@ func (h *Handler) handleStartJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Type == "" {
-		req.Type = "fuzzing"
-	}
-
 	logging.Info("Starting job: Target=%s WordlistID=%s Type=%s", req.Target, req.WordlistID, req.Type)
 
-	if err := h.fuzzerMgr.StartJob(req.Target, req.WordlistID, req.Type); err != nil {
+	if err := h.fuzzerMgr.StartJob(req.Target, req.WordlistID, req.Type, req.Options); err != nil {
 		logging.Error("Failed to start job: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, types.ErrShuttingDown):
+			status = http.StatusServiceUnavailable
+		case errors.Is(err, types.ErrInvalidJob):
+			status = http.StatusBadRequest
+		case errors.Is(err, scope.ErrOutOfScope):
+			status = http.StatusForbidden
+			h.record(r, "scope.violation", map[string]string{
+				"target":  req.Target,
+				"project": req.Options.Project,
+				"reason":  err.Error(),
+			})
+		}
+		http.Error(w, err.Error(), status)
 		return
 	}
 
+	// The most recent job for this target and wordlist is the one just created
 	jobs, _ := h.fuzzerMgr.GetJobs()
 	var createdJob *types.Job
 	for _, job := range jobs {
-		if job.Target == req.Target && job.WordlistID == req.WordlistID {
+		if job.Target == req.Target && job.WordlistID == req.WordlistID &&
+			(createdJob == nil || job.StartTime.After(createdJob.StartTime)) {
 			createdJob = job
-			break
 		}
 	}
 
+	params := map[string]string{
+		"target":     req.Target,
+		"wordlistId": req.WordlistID,
+		"type":       string(req.Type),
+		"project":    req.Options.Project,
+	}
+	if createdJob != nil {
+		params["jobId"] = createdJob.ID
+	}
+	h.record(r, "job.start", params)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(createdJob)
 }
@@ -142,42 +266,425 @@ func (h *Handler) handleStopJob(w http.ResponseWriter, r *http.Request) {
 
 	logging.Info("Stopping job: JobID=%s", req.JobID)
 
+	target := h.jobTarget(req.JobID)
 	if err := h.fuzzerMgr.StopJob(req.JobID); err != nil {
 		logging.Error("Failed to stop job: %v", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	h.record(r, "job.stop", map[string]string{"jobId": req.JobID, "target": target})
 	w.WriteHeader(http.StatusOK)
 }
 
-func (h *Handler) handleAddWordlist(w http.ResponseWriter, r *http.Request) {
+// maxUploadMemory is how much of a wordlist upload is buffered in memory;
+// the rest is spooled to a temporary file
+const maxUploadMemory = 1 << 20
+
+// normalizeOptions reads the upload clean-up options from the form. Every
+// step is on unless its field is set to false.
+func normalizeOptions(r *http.Request) (wordlist.NormalizeOptions, error) {
+	opts := wordlist.DefaultNormalizeOptions()
+	fields := []struct {
+		name string
+		dst  *bool
+	}{
+		{"trim", &opts.Trim},
+		{"dropComments", &opts.DropComments},
+		{"dropBlank", &opts.DropBlank},
+		{"dedupe", &opts.Dedupe},
+	}
+	for _, f := range fields {
+		if v := r.FormValue(f.name); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return opts, fmt.Errorf("%s must be true or false", f.name)
+			}
+			*f.dst = b
+		}
+	}
+	if v := r.FormValue("encoding"); v != "" {
+		opts.Encoding = v
+	}
+	return opts, nil
+}
+
+// wordlistUpload is the multipart "wordlist" file of a request, read
+// through a normalizer configured from the form
+type wordlistUpload struct {
+	*wordlist.Normalizer
+	file     multipart.File
+	filename string
+}
+
+func (u *wordlistUpload) Close() error {
+	return u.file.Close()
+}
+
+func readWordlistUpload(r *http.Request) (*wordlistUpload, error) {
+	if err := r.ParseMultipartForm(maxUploadMemory); err != nil {
+		return nil, err
+	}
 	file, header, err := r.FormFile("wordlist")
 	if err != nil {
-		logging.Error("Failed to get wordlist file: %v", err)
+		return nil, err
+	}
+	opts, err := normalizeOptions(r)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	normalizer, err := wordlist.NewNormalizer(file, opts)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &wordlistUpload{Normalizer: normalizer, file: file, filename: header.Filename}, nil
+}
+
+// wordlistParams describes a stored wordlist for audit entries. The checksum
+// identifies exactly what was stored.
+func wordlistParams(wl *types.Wordlist, filename string, stats wordlist.NormalizeStats) map[string]string {
+	return map[string]string{
+		"wordlistId": wl.ID,
+		"filename":   filename,
+		"words":      strconv.Itoa(wl.Lines),
+		"dropped":    strconv.Itoa(stats.Dropped()),
+		"sha256":     wl.Checksum,
+	}
+}
+
+func (h *Handler) handleAddWordlist(w http.ResponseWriter, r *http.Request) {
+	upload, err := readWordlistUpload(r)
+	if err != nil {
+		logging.Error("Failed to read wordlist upload: %v", err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	defer file.Close()
+	defer upload.Close()
 
-	logging.Info("Adding wordlist: %s", header.Filename)
+	logging.Info("Adding wordlist: %s", upload.filename)
+
+	info := types.WordlistInfo{
+		Description: r.FormValue("description"),
+		Tags:        strings.Split(r.FormValue("tags"), ","),
+		Source:      r.FormValue("source"),
+		CreatedBy:   actorName(r),
+	}
+	if info.Source == "" {
+		info.Source = upload.filename
+	}
+	id, err := h.wordlistMgr.Add(r.FormValue("name"), upload, info)
+	if err != nil {
+		logging.Error("Failed to add wordlist: %v", err)
+		wordlistError(w, err)
+		return
+	}
+	stats := upload.Stats()
+	logging.Info("Added wordlist with ID: %s (kept %d of %d lines)", id, stats.Kept, stats.Read)
+	if wl := h.wordlistMgr.Get(id); wl != nil {
+		params := wordlistParams(wl, upload.filename, stats)
+		params["name"] = r.FormValue("name")
+		h.record(r, "wordlist.add", params)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"id": id, "stats": stats})
+}
+
+func (h *Handler) handleDeriveWordlist(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name        string   `json:"name"`
+		BaseID      string   `json:"baseId"`
+		Rules       []string `json:"rules"`
+		Description string   `json:"description"`
+		Tags        []string `json:"tags"`
+	}
 
-	var words []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		words = append(words, scanner.Text())
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.Error("Invalid derive wordlist request: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		http.Error(w, "name must not be empty", http.StatusBadRequest)
+		return
 	}
 
-	if err := scanner.Err(); err != nil {
-		logging.Error("Error reading wordlist file: %v", err)
-		http.Error(w, "Error reading wordlist", http.StatusInternalServerError)
+	id, err := h.wordlistMgr.Derive(req.Name, req.BaseID, req.Rules)
+	if err != nil {
+		logging.Error("Failed to derive wordlist: %v", err)
+		wordlistError(w, err)
+		return
+	}
+	info := types.WordlistInfo{Description: req.Description, Tags: req.Tags, CreatedBy: actorName(r)}
+	if err := h.wordlistMgr.Describe(id, info); err != nil {
+		logging.Error("Failed to describe wordlist %s: %v", id, err)
+		wordlistError(w, err)
 		return
 	}
 
-	id := h.wordlistMgr.Add(r.FormValue("name"), words)
-	logging.Info("Added wordlist with ID: %s", id)
+	h.record(r, "wordlist.derive", map[string]string{
+		"wordlistId": id,
+		"name":       req.Name,
+		"base":       req.BaseID,
+		"rules":      strings.Join(req.Rules, "\n"),
+	})
+	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"id": id})
 }
 
+// runningJobsUsing returns the IDs of running jobs that read the wordlist
+func (h *Handler) runningJobsUsing(wordlistID string) []string {
+	jobs, _ := h.fuzzerMgr.GetJobs()
+	var ids []string
+	for _, job := range jobs {
+		if job.Status == "running" && job.WordlistID == wordlistID {
+			ids = append(ids, job.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// wordlistError writes the status matching a wordlist manager error
+func wordlistError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, wordlist.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, wordlist.ErrInvalidWordlist):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, wordlist.ErrInUse):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, wordlist.ErrReadOnly):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) handleDeleteWordlist(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID    string `json:"id"`
+		Force bool   `json:"force"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.Error("Invalid delete wordlist request: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if h.wordlistMgr.Get(req.ID) == nil {
+		http.Error(w, wordlist.ErrNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Running jobs keep reading the file they opened, but deleting the list
+	// from under them is usually a mistake, so it has to be forced
+	resp := map[string]string{}
+	if inUse := h.runningJobsUsing(req.ID); len(inUse) > 0 {
+		msg := fmt.Sprintf("wordlist is used by running jobs: %s", strings.Join(inUse, ", "))
+		if !req.Force {
+			logging.Error("Refused to delete wordlist %s: %s", req.ID, msg)
+			http.Error(w, msg+" (set force to delete anyway)", http.StatusConflict)
+			return
+		}
+		resp["warning"] = msg
+	}
+
+	logging.Info("Deleting wordlist: ID=%s", req.ID)
+	if err := h.wordlistMgr.Delete(req.ID); err != nil {
+		logging.Error("Failed to delete wordlist: %v", err)
+		wordlistError(w, err)
+		return
+	}
+
+	h.record(r, "wordlist.delete", map[string]string{
+		"wordlistId": req.ID,
+		"forced":     strconv.FormatBool(resp["warning"] != ""),
+	})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *Handler) handleRenameWordlist(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.Error("Invalid rename wordlist request: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		http.Error(w, "name must not be empty", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.wordlistMgr.Rename(req.ID, req.Name); err != nil {
+		logging.Error("Failed to rename wordlist: %v", err)
+		wordlistError(w, err)
+		return
+	}
+
+	h.record(r, "wordlist.rename", map[string]string{"wordlistId": req.ID, "name": req.Name})
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleDescribeWordlist updates a list's description, tags and source. Who
+// created the list is kept.
+func (h *Handler) handleDescribeWordlist(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID          string   `json:"id"`
+		Description string   `json:"description"`
+		Tags        []string `json:"tags"`
+		Source      string   `json:"source"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.Error("Invalid describe wordlist request: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	wl := h.wordlistMgr.Get(req.ID)
+	if wl == nil {
+		http.Error(w, wordlist.ErrNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	info := types.WordlistInfo{
+		Description: req.Description,
+		Tags:        req.Tags,
+		Source:      req.Source,
+		CreatedBy:   wl.CreatedBy,
+	}
+	if err := h.wordlistMgr.Describe(req.ID, info); err != nil {
+		logging.Error("Failed to describe wordlist: %v", err)
+		wordlistError(w, err)
+		return
+	}
+
+	h.record(r, "wordlist.describe", map[string]string{
+		"wordlistId":  req.ID,
+		"description": req.Description,
+		"tags":        strings.Join(wordlist.NormalizeTags(req.Tags), ","),
+		"source":      req.Source,
+	})
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) handleReplaceWordlist(w http.ResponseWriter, r *http.Request) {
+	upload, err := readWordlistUpload(r)
+	if err != nil {
+		logging.Error("Failed to read wordlist upload: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer upload.Close()
+
+	id := r.FormValue("id")
+	logging.Info("Replacing wordlist: ID=%s File=%s", id, upload.filename)
+
+	if err := h.wordlistMgr.Replace(id, upload); err != nil {
+		logging.Error("Failed to replace wordlist: %v", err)
+		wordlistError(w, err)
+		return
+	}
+
+	stats := upload.Stats()
+	if wl := h.wordlistMgr.Get(id); wl != nil {
+		h.record(r, "wordlist.replace", wordlistParams(wl, upload.filename, stats))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"stats": stats})
+}
+
+func (h *Handler) handleAppendWordlist(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID    string   `json:"id"`
+		Words []string `json:"words"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.Error("Invalid append wordlist request: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.wordlistMgr.Append(req.ID, req.Words); err != nil {
+		logging.Error("Failed to append to wordlist: %v", err)
+		wordlistError(w, err)
+		return
+	}
+
+	h.record(r, "wordlist.append", map[string]string{
+		"wordlistId": req.ID,
+		"words":      strconv.Itoa(len(req.Words)),
+	})
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) handleDownloadWordlist(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	wl := h.wordlistMgr.Get(id)
+	if wl == nil {
+		http.Error(w, wordlist.ErrNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	filename := wl.Name
+	if !strings.HasSuffix(filename, ".txt") {
+		filename += ".txt"
+	}
+	attachment := func() {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	}
+
+	// Derived, generated, builtin and learned lists have no file, so their
+	// words are written out as they are read
+	if wl.Base != "" || wl.Builtin || wordlist.IsGenerator(id) || wordlist.IsLearned(id) {
+		words, err := h.wordlistMgr.Iterate(id)
+		if err != nil {
+			logging.Error("Failed to open wordlist %s: %v", id, err)
+			wordlistError(w, err)
+			return
+		}
+		defer words.Close()
+
+		attachment()
+		bw := bufio.NewWriter(w)
+		// Generators can be enormous, so stop once the client goes away
+		for words.Next() && r.Context().Err() == nil {
+			bw.WriteString(words.Word())
+			bw.WriteByte('\n')
+		}
+		if err := words.Err(); err != nil {
+			logging.Error("Failed to read wordlist %s: %v", id, err)
+		}
+		bw.Flush()
+		return
+	}
+
+	file, err := h.wordlistMgr.Open(id)
+	if err != nil {
+		logging.Error("Failed to open wordlist %s: %v", id, err)
+		wordlistError(w, err)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	attachment()
+	http.ServeContent(w, r, filename, info.ModTime(), file)
+}
+
 func (h *Handler) handleUpdateRateLimit(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		RateLimit float64 `json:"rateLimit"`
@@ -189,14 +696,106 @@ func (h *Handler) handleUpdateRateLimit(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if req.RateLimit <= 0 {
+		http.Error(w, "rateLimit must be positive", http.StatusBadRequest)
+		return
+	}
+
 	logging.Info("Updating rate limit to: %f", req.RateLimit)
-	// Uncomment when implementation is ready
-	// h.fuzzerMgr.UpdateRateLimit(req.RateLimit)
+	h.fuzzerMgr.UpdateRateLimit(req.RateLimit)
+	h.record(r, "rate_limit.update", map[string]string{
+		"rateLimit": strconv.FormatFloat(req.RateLimit, 'f', -1, 64),
+	})
 	w.WriteHeader(http.StatusOK)
 }
 
+// jobTarget looks up a job's target so audit entries name what was affected
+func (h *Handler) jobTarget(jobID string) string {
+	jobs, _ := h.fuzzerMgr.GetJobs()
+	for _, job := range jobs {
+		if job.ID == jobID {
+			return job.Target
+		}
+	}
+	return ""
+}
+
+func (h *Handler) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if h.audit == nil {
+		http.Error(w, "audit log is not enabled", http.StatusNotFound)
+		return
+	}
+
+	q := audit.Query{
+		Actor:  r.URL.Query().Get("actor"),
+		Action: r.URL.Query().Get("action"),
+	}
+	for name, dst := range map[string]*time.Time{"since": &q.Since, "until": &q.Until} {
+		if v := r.URL.Query().Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("%s must be an RFC 3339 time", name), http.StatusBadRequest)
+				return
+			}
+			*dst = t
+		}
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			http.Error(w, "limit must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		q.Limit = limit
+	}
+
+	entries := h.audit.Query(q)
+	logging.Info("Retrieved %d audit entries", len(entries))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+func (h *Handler) handleAuditVerify(w http.ResponseWriter, r *http.Request) {
+	if h.audit == nil {
+		http.Error(w, "audit log is not enabled", http.StatusNotFound)
+		return
+	}
+
+	resp := map[string]interface{}{"valid": true}
+	if err := h.audit.Verify(); err != nil {
+		logging.Error("Audit log verification failed: %v", err)
+		resp["valid"] = false
+		resp["error"] = err.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleWordlists lists the wordlists matching the optional search and tag
+// query parameters. tag may be repeated or comma-separated, and lists must
+// carry every tag given.
 func (h *Handler) handleWordlists(w http.ResponseWriter, r *http.Request) {
-	wordlists := h.wordlistMgr.List()
+	query := r.URL.Query()
+	var tags []string
+	for _, tag := range query["tag"] {
+		tags = append(tags, strings.Split(tag, ",")...)
+	}
+
+	hits := h.fuzzerMgr.WordlistHits()
+	wordlists := wordlist.Filter(h.wordlistMgr.List(), query.Get("search"), tags)
+	for i, wl := range wordlists {
+		// Lists from the manager are shared, so hits go on a copy
+		withHits := *wl
+		withHits.Hits = hits[wl.ID]
+		wordlists[i] = &withHits
+	}
+	sort.Slice(wordlists, func(i, j int) bool { return wordlists[i].Name < wordlists[j].Name })
+	if wordlists == nil {
+		wordlists = []*types.Wordlist{}
+	}
+
 	logging.Info("Retrieved %d wordlists", len(wordlists))
+	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(wordlists)
 }
@@ -3,10 +3,16 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"fuzzer/internal/audit"
+	"fuzzer/internal/auth"
+	"fuzzer/internal/wordlist"
 	"fuzzer/types"
 
 	"github.com/stretchr/testify/assert"
@@ -17,8 +23,8 @@ type MockFuzzerManager struct {
 	mock.Mock
 }
 
-func (m *MockFuzzerManager) StartJob(target, wordlistID string, jobType types.JobType) error {
-	args := m.Called(target, wordlistID, jobType)
+func (m *MockFuzzerManager) StartJob(target, wordlistID string, jobType types.JobType, opts types.JobOptions) error {
+	args := m.Called(target, wordlistID, jobType, opts)
 	return args.Error(0)
 }
 
@@ -37,6 +43,15 @@ func (m *MockFuzzerManager) DeleteJob(jobID string) error {
 	return args.Error(0)
 }
 
+func (m *MockFuzzerManager) UpdateRateLimit(newLimit float64) {
+	m.Called(newLimit)
+}
+
+func (m *MockFuzzerManager) WordlistHits() map[string]int {
+	args := m.Called()
+	return args.Get(0).(map[string]int)
+}
+
 func TestHandleStartJob(t *testing.T) {
 	mockFuzzer := new(MockFuzzerManager)
 	handler := NewHandler(mockFuzzer, nil, nil)
@@ -51,7 +66,7 @@ func TestHandleStartJob(t *testing.T) {
 	}
 
 	// Mock both StartJob and GetJobs calls
-	mockFuzzer.On("StartJob", "http://example.com", "test-wordlist", types.DirectoryType).Return(nil)
+	mockFuzzer.On("StartJob", "http://example.com", "test-wordlist", types.DirectoryType, types.JobOptions{}).Return(nil)
 	mockFuzzer.On("GetJobs").Return([]*types.Job{testJob}, nil)
 
 	body := map[string]string{
@@ -105,3 +120,243 @@ func TestHandleGetJobs(t *testing.T) {
 	assert.Len(t, response, 1)
 	assert.Equal(t, "test-job", response[0].ID)
 }
+
+type staticAuthenticator map[string]*auth.Token
+
+func (a staticAuthenticator) Authenticate(secret string) (*auth.Token, error) {
+	if token, ok := a[secret]; ok {
+		return token, nil
+	}
+	return nil, auth.ErrInvalidToken
+}
+
+func TestServeHTTPEnforcesRoles(t *testing.T) {
+	mockFuzzer := new(MockFuzzerManager)
+	mockFuzzer.On("GetJobs").Return([]*types.Job{})
+	mockFuzzer.On("StopJob", "job-1").Return(nil)
+
+	handler := NewHandler(mockFuzzer, nil, nil)
+	handler.RequireAuth(staticAuthenticator{
+		"fz_viewer":   {Name: "viewer", Role: auth.RoleViewer},
+		"fz_operator": {Name: "operator", Role: auth.RoleOperator},
+	})
+
+	tests := []struct {
+		name   string
+		path   string
+		token  string
+		status int
+	}{
+		{"missing token", "/api/jobs", "", http.StatusUnauthorized},
+		{"invalid token", "/api/jobs", "fz_nope", http.StatusUnauthorized},
+		{"viewer can list", "/api/jobs", "fz_viewer", http.StatusOK},
+		{"viewer cannot stop", "/api/jobs/stop", "fz_viewer", http.StatusForbidden},
+		{"operator can stop", "/api/jobs/stop", "fz_operator", http.StatusOK},
+		{"operator cannot change rate limit", "/api/rate-limit", "fz_operator", http.StatusForbidden},
+		{"unknown route", "/api/nope", "fz_operator", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.path, bytes.NewBufferString(`{"jobId":"job-1"}`))
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestJobActionsAreAudited(t *testing.T) {
+	mockFuzzer := new(MockFuzzerManager)
+	mockFuzzer.On("GetJobs").Return([]*types.Job{{ID: "job-1", Target: "http://example.com"}})
+	mockFuzzer.On("StopJob", "job-1").Return(nil)
+
+	auditLog, err := audit.Open(filepath.Join(t.TempDir(), "audit.log"))
+	assert.NoError(t, err)
+	defer auditLog.Close()
+
+	handler := NewHandler(mockFuzzer, nil, nil)
+	handler.RequireAuth(staticAuthenticator{
+		"fz_operator": {Name: "alice", Role: auth.RoleOperator},
+		"fz_admin":    {Name: "root", Role: auth.RoleAdmin},
+	})
+	handler.SetAuditLog(auditLog)
+
+	req := httptest.NewRequest("POST", "/api/jobs/stop", bytes.NewBufferString(`{"jobId":"job-1"}`))
+	req.Header.Set("Authorization", "Bearer fz_operator")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req = httptest.NewRequest("GET", "/api/audit?actor=alice", nil)
+	req.Header.Set("Authorization", "Bearer fz_admin")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var entries []audit.Entry
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "job.stop", entries[0].Action)
+	assert.Equal(t, "job-1", entries[0].Params["jobId"])
+	assert.Equal(t, "http://example.com", entries[0].Params["target"])
+
+	// Rate limit changes are applied before they are audited, and invalid
+	// ones are neither
+	mockFuzzer.On("UpdateRateLimit", 25.0).Return()
+	for _, body := range []string{`{"rateLimit":25}`, `{"rateLimit":0}`} {
+		req = httptest.NewRequest("POST", "/api/rate-limit", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer fz_admin")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	mockFuzzer.AssertNumberOfCalls(t, "UpdateRateLimit", 1)
+
+	req = httptest.NewRequest("GET", "/api/audit?action=rate_limit.update", nil)
+	req.Header.Set("Authorization", "Bearer fz_admin")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	entries = nil
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "25", entries[0].Params["rateLimit"])
+	}
+}
+
+func TestDeleteWordlistInUse(t *testing.T) {
+	wordlistMgr, err := wordlist.NewManager(t.TempDir())
+	assert.NoError(t, err)
+	id, err := wordlistMgr.Add("dirs", strings.NewReader("admin\n"), types.WordlistInfo{})
+	assert.NoError(t, err)
+
+	mockFuzzer := new(MockFuzzerManager)
+	mockFuzzer.On("GetJobs").Return([]*types.Job{{ID: "job-1", WordlistID: id, Status: "running"}})
+	handler := NewHandler(mockFuzzer, wordlistMgr, nil)
+
+	// Refused while a running job uses it
+	req := httptest.NewRequest("POST", "/api/wordlists/delete", bytes.NewBufferString(`{"id":"`+id+`"}`))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "job-1")
+	assert.NotNil(t, wordlistMgr.Get(id))
+
+	// Forcing deletes it with a warning
+	req = httptest.NewRequest("POST", "/api/wordlists/delete", bytes.NewBufferString(`{"id":"`+id+`","force":true}`))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "warning")
+	assert.Nil(t, wordlistMgr.Get(id))
+
+	// Unknown lists are a 404
+	req = httptest.NewRequest("POST", "/api/wordlists/delete", bytes.NewBufferString(`{"id":"`+id+`"}`))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// uploadRequest builds a multipart wordlist upload with the given form fields
+func uploadRequest(t *testing.T, path, content string, fields map[string]string) *http.Request {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("wordlist", "dirs.txt")
+	assert.NoError(t, err)
+	part.Write([]byte(content))
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	assert.NoError(t, mw.Close())
+
+	req := httptest.NewRequest("POST", path, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestAddWordlistNormalizes(t *testing.T) {
+	wordlistMgr, err := wordlist.NewManager(t.TempDir())
+	assert.NoError(t, err)
+	handler := NewHandler(new(MockFuzzerManager), wordlistMgr, nil)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, uploadRequest(t, "/api/wordlists/add", "admin\r\n# comment\r\nadmin\r\n\r\nlogin\r\n", map[string]string{"name": "dirs"}))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		ID    string                  `json:"id"`
+		Stats wordlist.NormalizeStats `json:"stats"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, wordlist.NormalizeStats{Read: 5, Kept: 2, Blank: 1, Comments: 1, Duplicates: 1}, resp.Stats)
+	assert.Equal(t, 2, wordlistMgr.Get(resp.ID).Lines)
+
+	// Steps can be turned off per upload
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, uploadRequest(t, "/api/wordlists/replace", "admin\nadmin\n", map[string]string{"id": resp.ID, "dedupe": "false"}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, wordlistMgr.Get(resp.ID).Lines)
+
+	// Content problems are the client's fault
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, uploadRequest(t, "/api/wordlists/add", "caf\xe9\n", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "not valid UTF-8")
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, uploadRequest(t, "/api/wordlists/add", "admin\n", map[string]string{"trim": "maybe"}))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestListWordlistsFiltersAndAddsHits(t *testing.T) {
+	wordlistMgr, err := wordlist.NewManager(t.TempDir())
+	assert.NoError(t, err)
+	mockFuzzer := new(MockFuzzerManager)
+	handler := NewHandler(mockFuzzer, wordlistMgr, nil)
+	handler.RequireAuth(staticAuthenticator{"fz_operator": {Name: "alice", Role: auth.RoleOperator}})
+
+	req := uploadRequest(t, "/api/wordlists/add", "admin\nlogin\n", map[string]string{
+		"name": "dirs", "tags": "web, Directory", "description": "Admin panels",
+	})
+	req.Header.Set("Authorization", "Bearer fz_operator")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var added struct {
+		ID string `json:"id"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &added))
+	_, err = wordlistMgr.Add("hosts", strings.NewReader("www\n"), types.WordlistInfo{})
+	assert.NoError(t, err)
+
+	mockFuzzer.On("WordlistHits").Return(map[string]int{added.ID: 3})
+
+	list := func(query string) []*types.Wordlist {
+		req := httptest.NewRequest("GET", "/api/wordlists"+query, nil)
+		req.Header.Set("Authorization", "Bearer fz_operator")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var lists []*types.Wordlist
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &lists))
+		return lists
+	}
+
+	lists := list("")
+	assert.Len(t, lists, 2)
+	assert.Equal(t, "dirs", lists[0].Name)
+	assert.Equal(t, 3, lists[0].Hits)
+	assert.Equal(t, []string{"directory", "web"}, lists[0].Tags)
+	assert.Equal(t, "alice", lists[0].CreatedBy)
+	assert.Equal(t, "dirs.txt", lists[0].Source)
+	assert.Equal(t, 0, lists[1].Hits)
+
+	assert.Len(t, list("?search=panels"), 1)
+	assert.Len(t, list("?tag=web&tag=directory"), 1)
+	assert.Empty(t, list("?tag=web,dns"))
+
+	// The manager's copy is left without hits
+	assert.Equal(t, 0, wordlistMgr.Get(added.ID).Hits)
+}
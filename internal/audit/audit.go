@@ -0,0 +1,220 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"fuzzer/internal/logging"
+)
+
+// genesisHash is the PrevHash of the first entry in a log
+var genesisHash = strings.Repeat("0", 64)
+
+// ErrTampered is wrapped by Verify when the hash chain is broken
+var ErrTampered = errors.New("audit log has been modified")
+
+// Entry is a single audit record. Hash covers every other field, including
+// PrevHash, so changing or removing any entry breaks the chain after it.
+type Entry struct {
+	Seq      int64             `json:"seq"`
+	Time     time.Time         `json:"time"`
+	Actor    string            `json:"actor"`
+	Action   string            `json:"action"`
+	Params   map[string]string `json:"params,omitempty"`
+	PrevHash string            `json:"prevHash"`
+	Hash     string            `json:"hash"`
+}
+
+// computeHash returns the hash of the entry with its Hash field cleared
+func (e Entry) computeHash() string {
+	e.Hash = ""
+	// Struct fields marshal in a fixed order and map keys are sorted, so
+	// the encoding is stable
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Query filters entries. Zero fields match everything.
+type Query struct {
+	Actor  string
+	Action string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+func (q Query) matches(e Entry) bool {
+	if q.Actor != "" && e.Actor != q.Actor {
+		return false
+	}
+	if q.Action != "" && e.Action != q.Action && !strings.HasPrefix(e.Action, q.Action+".") {
+		return false
+	}
+	if !q.Since.IsZero() && e.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && e.Time.After(q.Until) {
+		return false
+	}
+	return true
+}
+
+// Log is an append-only, hash-chained audit log stored as JSON lines
+type Log struct {
+	filename string
+	file     *os.File
+	entries  []Entry
+	mu       sync.RWMutex
+}
+
+// Open loads an existing log, or creates a new one, and opens it for
+// appending. A broken chain is reported but does not stop the log from
+// being used; Verify keeps reporting it.
+func Open(filepath string) (*Log, error) {
+	l := &Log{filename: filepath}
+
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(filepath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		logging.Error("Failed to open audit log %s: %v", filepath, err)
+		return nil, err
+	}
+	l.file = file
+
+	if err := l.Verify(); err != nil {
+		logging.Error("Audit log verification failed: %v", err)
+	}
+	logging.Info("Opened audit log %s with %d entries", filepath, len(l.entries))
+	return l, nil
+}
+
+func (l *Log) load() error {
+	file, err := os.Open(l.filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		logging.Error("Failed to read audit log %s: %v", l.filename, err)
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("%w: line %d is not a valid entry: %v", ErrTampered, line, err)
+		}
+		l.entries = append(l.entries, e)
+	}
+	return scanner.Err()
+}
+
+// Record appends an entry and syncs it to disk
+func (l *Log) Record(actor, action string, params map[string]string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry := Entry{
+		Seq:      1,
+		Time:     time.Now().UTC(),
+		Actor:    actor,
+		Action:   action,
+		Params:   params,
+		PrevHash: genesisHash,
+	}
+	if n := len(l.entries); n > 0 {
+		entry.Seq = l.entries[n-1].Seq + 1
+		entry.PrevHash = l.entries[n-1].Hash
+	}
+	entry.Hash = entry.computeHash()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		logging.Error("Failed to write audit entry: %v", err)
+		return err
+	}
+	if err := l.file.Sync(); err != nil {
+		logging.Error("Failed to sync audit log: %v", err)
+		return err
+	}
+
+	l.entries = append(l.entries, entry)
+	logging.Audit("seq=%d actor=%q action=%s params=%v", entry.Seq, actor, action, params)
+	return nil
+}
+
+// Query returns matching entries, newest first
+func (l *Log) Query(q Query) []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	result := make([]Entry, 0)
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		if q.matches(l.entries[i]) {
+			result = append(result, l.entries[i])
+			if q.Limit > 0 && len(result) >= q.Limit {
+				break
+			}
+		}
+	}
+	return result
+}
+
+// Verify re-reads the log from disk and checks every hash and link in the
+// chain, so edits made to the file behind the server's back are detected
+func (l *Log) Verify() error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	onDisk := &Log{filename: l.filename}
+	if err := onDisk.load(); err != nil {
+		return err
+	}
+
+	prev := genesisHash
+	for i, e := range onDisk.entries {
+		if e.Seq != int64(i+1) {
+			return fmt.Errorf("%w: entry %d has sequence number %d", ErrTampered, i+1, e.Seq)
+		}
+		if e.PrevHash != prev {
+			return fmt.Errorf("%w: entry %d does not link to the previous entry", ErrTampered, e.Seq)
+		}
+		if e.computeHash() != e.Hash {
+			return fmt.Errorf("%w: entry %d does not match its hash", ErrTampered, e.Seq)
+		}
+		prev = e.Hash
+	}
+
+	if len(onDisk.entries) < len(l.entries) {
+		return fmt.Errorf("%w: %d entries are missing from the end", ErrTampered, len(l.entries)-len(onDisk.entries))
+	}
+	return nil
+}
+
+// Close closes the underlying file
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.file.Close()
+}
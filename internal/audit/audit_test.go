@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	log, err := Open(path)
+	assert.NoError(t, err)
+
+	assert.NoError(t, log.Record("alice", "job.start", map[string]string{"jobId": "job-1", "target": "http://a.test"}))
+	assert.NoError(t, log.Record("bob", "wordlist.add", map[string]string{"name": "dirs.txt"}))
+	assert.NoError(t, log.Record("alice", "job.stop", map[string]string{"jobId": "job-1"}))
+	assert.NoError(t, log.Verify())
+	assert.NoError(t, log.Close())
+
+	// The chain continues across restarts
+	log, err = Open(path)
+	assert.NoError(t, err)
+	defer log.Close()
+	assert.NoError(t, log.Record("alice", "job.delete", map[string]string{"jobId": "job-1"}))
+	assert.NoError(t, log.Verify())
+
+	entries := log.Query(Query{})
+	assert.Len(t, entries, 4)
+	assert.Equal(t, int64(4), entries[0].Seq)
+	assert.Equal(t, entries[1].Hash, entries[0].PrevHash)
+
+	assert.Len(t, log.Query(Query{Actor: "alice"}), 3)
+	assert.Len(t, log.Query(Query{Action: "job"}), 3)
+	assert.Len(t, log.Query(Query{Action: "job.stop"}), 1)
+	assert.Len(t, log.Query(Query{Limit: 2}), 2)
+}
+
+func TestAuditLogDetectsTampering(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	log, err := Open(path)
+	assert.NoError(t, err)
+	defer log.Close()
+
+	assert.NoError(t, log.Record("alice", "job.start", map[string]string{"target": "http://a.test"}))
+	assert.NoError(t, log.Record("alice", "job.stop", nil))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	// Rewriting who did something breaks that entry's hash
+	edited := strings.Replace(string(data), `"actor":"alice"`, `"actor":"mallory"`, 1)
+	assert.NoError(t, os.WriteFile(path, []byte(edited), 0600))
+	assert.ErrorIs(t, log.Verify(), ErrTampered)
+
+	// Dropping an entry breaks the chain
+	lines := strings.SplitAfter(string(data), "\n")
+	assert.NoError(t, os.WriteFile(path, []byte(lines[1]), 0600))
+	assert.ErrorIs(t, log.Verify(), ErrTampered)
+
+	// Truncating the end is caught against what the server has written
+	assert.NoError(t, os.WriteFile(path, []byte(lines[0]), 0600))
+	assert.ErrorIs(t, log.Verify(), ErrTampered)
+}
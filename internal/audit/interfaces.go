@@ -0,0 +1,5 @@
+package audit
+
+type Recorder interface {
+	Record(actor, action string, params map[string]string) error
+}
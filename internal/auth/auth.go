@@ -0,0 +1,260 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fuzzer/internal/logging"
+	"fuzzer/utils"
+)
+
+// Role controls which API routes a token may call. Each role includes the
+// permissions of the roles below it.
+type Role string
+
+const (
+	// RoleViewer can list jobs and wordlists
+	RoleViewer Role = "viewer"
+	// RoleOperator can also start, stop and delete jobs and upload wordlists
+	RoleOperator Role = "operator"
+	// RoleAdmin can also change server-wide settings
+	RoleAdmin Role = "admin"
+)
+
+// tokenPrefix makes API tokens easy to recognise in logs and secret scanners
+const tokenPrefix = "fz_"
+
+var (
+	ErrInvalidToken = errors.New("invalid API token")
+	ErrTokenMissing = errors.New("token not found")
+)
+
+var roleRank = map[Role]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// ParseRole validates a role name
+func ParseRole(name string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(name)))
+	if _, ok := roleRank[role]; !ok {
+		return "", fmt.Errorf("unknown role %q (expected viewer, operator or admin)", name)
+	}
+	return role, nil
+}
+
+// Allows reports whether the role grants at least the required role
+func (r Role) Allows(required Role) bool {
+	return roleRank[r] >= roleRank[required]
+}
+
+// Token is a stored API token. Only the SHA-256 hash of the secret is kept.
+type Token struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name"`
+	Role    Role      `json:"role"`
+	Hash    string    `json:"hash"`
+	Created time.Time `json:"created"`
+}
+
+type TokenStore struct {
+	filename string
+	tokens   map[string]*Token
+	modTime  time.Time
+	mu       sync.RWMutex
+}
+
+func NewTokenStore(filepath string) (*TokenStore, error) {
+	store := &TokenStore{
+		filename: filepath,
+		tokens:   make(map[string]*Token),
+	}
+
+	if _, err := os.Stat(filepath); errors.Is(err, os.ErrNotExist) {
+		logging.Info("Creating new token store file: %s", filepath)
+		return store, nil
+	}
+
+	if err := store.load(); err != nil {
+		return nil, err
+	}
+	logging.Info("Loaded %d API tokens from %s", len(store.tokens), filepath)
+
+	return store, nil
+}
+
+// load reads the tokens from disk. The caller must hold s.mu or be the
+// constructor.
+func (s *TokenStore) load() error {
+	info, err := os.Stat(s.filename)
+	if err != nil {
+		logging.Error("Failed to stat token store file %s: %v", s.filename, err)
+		return err
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		logging.Error("Failed to read token store file %s: %v", s.filename, err)
+		return err
+	}
+
+	tokens := make(map[string]*Token)
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &tokens); err != nil {
+			logging.Error("Failed to unmarshal token store data: %v", err)
+			return err
+		}
+	}
+
+	s.tokens = tokens
+	s.modTime = info.ModTime()
+	return nil
+}
+
+// reloadIfChanged picks up tokens created or revoked by the CLI while the
+// server is running
+func (s *TokenStore) reloadIfChanged() {
+	info, err := os.Stat(s.filename)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if info.ModTime().Equal(s.modTime) {
+		return
+	}
+	if err := s.load(); err == nil {
+		logging.Info("Reloaded %d API tokens from %s", len(s.tokens), s.filename)
+	}
+}
+
+// Create issues a new token and returns its secret. The secret cannot be
+// recovered later since only its hash is stored.
+func (s *TokenStore) Create(name string, role Role) (string, *Token, error) {
+	if _, err := ParseRole(string(role)); err != nil {
+		return "", nil, err
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	secret := tokenPrefix + hex.EncodeToString(b)
+
+	token := &Token{
+		ID:      utils.GenerateID(),
+		Name:    name,
+		Role:    role,
+		Hash:    hashToken(secret),
+		Created: time.Now(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[token.ID] = token
+	if err := s.save(); err != nil {
+		delete(s.tokens, token.ID)
+		return "", nil, err
+	}
+
+	logging.Info("Created API token: ID=%s Name=%s Role=%s", token.ID, name, role)
+	return secret, token, nil
+}
+
+// Authenticate returns the token matching the given secret
+func (s *TokenStore) Authenticate(secret string) (*Token, error) {
+	if !strings.HasPrefix(secret, tokenPrefix) {
+		return nil, ErrInvalidToken
+	}
+	hash := []byte(hashToken(secret))
+
+	s.reloadIfChanged()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var match *Token
+	for _, token := range s.tokens {
+		if subtle.ConstantTimeCompare(hash, []byte(token.Hash)) == 1 {
+			match = token
+		}
+	}
+	if match == nil {
+		return nil, ErrInvalidToken
+	}
+	return match, nil
+}
+
+// List returns all tokens ordered by creation time
+func (s *TokenStore) List() []*Token {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tokens := make([]*Token, 0, len(s.tokens))
+	for _, token := range s.tokens {
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].Created.Before(tokens[j].Created)
+	})
+	return tokens
+}
+
+// Revoke deletes a token so it can no longer be used
+func (s *TokenStore) Revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, exists := s.tokens[id]
+	if !exists {
+		return ErrTokenMissing
+	}
+
+	delete(s.tokens, id)
+	if err := s.save(); err != nil {
+		s.tokens[id] = token
+		return err
+	}
+
+	logging.Info("Revoked API token: ID=%s Name=%s", id, token.Name)
+	return nil
+}
+
+// save writes the tokens to disk. The caller must hold s.mu.
+func (s *TokenStore) save() error {
+	data, err := json.MarshalIndent(s.tokens, "", "  ")
+	if err != nil {
+		logging.Error("Failed to marshal tokens: %v", err)
+		return err
+	}
+
+	// Hashes aren't secrets, but there's no reason for others to read them
+	if err := os.WriteFile(s.filename, data, 0600); err != nil {
+		logging.Error("Failed to write tokens to file %s: %v", s.filename, err)
+		return err
+	}
+
+	if info, err := os.Stat(s.filename); err == nil {
+		s.modTime = info.ModTime()
+	}
+	return nil
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
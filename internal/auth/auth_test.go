@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+
+	store, err := NewTokenStore(path)
+	assert.NoError(t, err)
+
+	secret, token, err := store.Create("ci", RoleOperator)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(secret, tokenPrefix))
+
+	// The secret itself must never be written to disk
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), secret)
+
+	// Tokens survive a reload
+	store, err = NewTokenStore(path)
+	assert.NoError(t, err)
+
+	authed, err := store.Authenticate(secret)
+	assert.NoError(t, err)
+	assert.Equal(t, token.ID, authed.ID)
+	assert.Equal(t, RoleOperator, authed.Role)
+
+	_, err = store.Authenticate(secret + "x")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	assert.NoError(t, store.Revoke(token.ID))
+	_, err = store.Authenticate(secret)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.ErrorIs(t, store.Revoke(token.ID), ErrTokenMissing)
+}
+
+func TestRoleAllows(t *testing.T) {
+	assert.True(t, RoleAdmin.Allows(RoleOperator))
+	assert.True(t, RoleOperator.Allows(RoleViewer))
+	assert.True(t, RoleViewer.Allows(RoleViewer))
+	assert.False(t, RoleViewer.Allows(RoleOperator))
+	assert.False(t, RoleOperator.Allows(RoleAdmin))
+	assert.False(t, Role("").Allows(RoleViewer))
+
+	_, err := ParseRole("superuser")
+	assert.Error(t, err)
+}
@@ -0,0 +1,5 @@
+package auth
+
+type Authenticator interface {
+	Authenticate(token string) (*Token, error)
+}
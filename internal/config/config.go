@@ -0,0 +1,374 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"fuzzer/internal/logging"
+	"fuzzer/internal/scope"
+	"fuzzer/types"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is prepended to every environment variable read by Load
+const EnvPrefix = "FUZZER_"
+
+// Config holds all server settings. Values are resolved in increasing order
+// of precedence: built-in defaults, the config file, environment variables
+// and finally command-line flags.
+type Config struct {
+	Server  ServerConfig  `yaml:"server"`
+	Storage StorageConfig `yaml:"storage"`
+	Fuzzer  FuzzerConfig  `yaml:"fuzzer"`
+	Log     LogConfig     `yaml:"log"`
+	Auth    AuthConfig    `yaml:"auth"`
+	Audit   AuditConfig   `yaml:"audit"`
+	Jobs    JobDefaults   `yaml:"jobs"`
+	// Scope applies to every job; a project's scope further restricts it
+	Scope    ScopeConfig              `yaml:"scope"`
+	Projects map[string]ProjectConfig `yaml:"projects"`
+}
+
+type ServerConfig struct {
+	Addr      string `yaml:"addr"`
+	StaticDir string `yaml:"static_dir"`
+	// ShutdownGrace bounds how long shutdown waits for jobs and requests
+	ShutdownGrace time.Duration `yaml:"shutdown_grace"`
+}
+
+type StorageConfig struct {
+	JobsFile    string `yaml:"jobs_file"`
+	WordlistDir string `yaml:"wordlist_dir"`
+}
+
+type FuzzerConfig struct {
+	RateLimit float64 `yaml:"rate_limit"`
+}
+
+type LogConfig struct {
+	Level string `yaml:"level"`
+}
+
+type AuthConfig struct {
+	// Enabled requires an API token on every /api/ request
+	Enabled    bool   `yaml:"enabled"`
+	TokensFile string `yaml:"tokens_file"`
+}
+
+type AuditConfig struct {
+	// File is the append-only, hash-chained audit log
+	File string `yaml:"file"`
+}
+
+// ScopeConfig lists scope rules. See scope.ParseRule for the syntax.
+type ScopeConfig struct {
+	Allow []string `yaml:"allow"`
+	Deny  []string `yaml:"deny"`
+}
+
+type ProjectConfig struct {
+	Scope ScopeConfig `yaml:"scope"`
+}
+
+// JobDefaults are applied to every job that does not set its own value
+type JobDefaults struct {
+	Timeout     time.Duration     `yaml:"timeout"`
+	Headers     map[string]string `yaml:"headers"`
+	MatchStatus []int             `yaml:"match_status"`
+}
+
+// Default returns the configuration used when nothing else is provided
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:          ":8080",
+			StaticDir:     "./web/static",
+			ShutdownGrace: 30 * time.Second,
+		},
+		Storage: StorageConfig{
+			JobsFile:    "jobs.json",
+			WordlistDir: "wordlists",
+		},
+		Fuzzer: FuzzerConfig{
+			RateLimit: 10.0,
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+		Auth: AuthConfig{
+			Enabled:    true,
+			TokensFile: "tokens.json",
+		},
+		Audit: AuditConfig{
+			File: "audit.log",
+		},
+		Jobs: JobDefaults{
+			Timeout:     10 * time.Second,
+			Headers:     map[string]string{},
+			MatchStatus: []int{200, 403},
+		},
+		Scope: ScopeConfig{
+			// Internal addresses are off limits unless the config sets its own
+			// deny list. Link-local covers cloud metadata endpoints such as
+			// 169.254.169.254.
+			Deny: []string{
+				"127.0.0.0/8", "::1",
+				"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7",
+				"169.254.0.0/16", "fe80::/10",
+			},
+		},
+		Projects: map[string]ProjectConfig{},
+	}
+}
+
+// Load resolves the configuration from defaults, an optional YAML file, the
+// environment and the given command-line arguments, then validates it. The
+// config file is named by the -config flag or the FUZZER_CONFIG variable.
+func Load(args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	fs := flag.NewFlagSet("http-fuzzer", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	configPath := fs.String("config", "", "path to a YAML config file")
+	addr := fs.String("addr", "", "address to listen on")
+	staticDir := fs.String("static-dir", "", "directory containing the web UI")
+	jobsFile := fs.String("jobs-file", "", "file used to persist jobs")
+	wordlistDir := fs.String("wordlist-dir", "", "directory used to store wordlists")
+	rateLimit := fs.Float64("rate-limit", 0, "requests per second across all jobs")
+	logLevel := fs.String("log-level", "", "log level (info or debug)")
+	timeout := fs.Duration("job-timeout", 0, "default per-request timeout for jobs")
+	grace := fs.Duration("shutdown-grace", 0, "time allowed for a graceful shutdown")
+	authEnabled := fs.Bool("auth", true, "require API tokens")
+	tokensFile := fs.String("tokens-file", "", "file used to store API token hashes")
+	auditFile := fs.String("audit-file", "", "append-only audit log file")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("invalid command-line arguments: %w", err)
+	}
+
+	cfg := Default()
+
+	path := *configPath
+	if path == "" {
+		path, _ = lookupEnv(EnvPrefix + "CONFIG")
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.applyEnv(lookupEnv); err != nil {
+		return nil, err
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "addr":
+			cfg.Server.Addr = *addr
+		case "static-dir":
+			cfg.Server.StaticDir = *staticDir
+		case "jobs-file":
+			cfg.Storage.JobsFile = *jobsFile
+		case "wordlist-dir":
+			cfg.Storage.WordlistDir = *wordlistDir
+		case "rate-limit":
+			cfg.Fuzzer.RateLimit = *rateLimit
+		case "log-level":
+			cfg.Log.Level = *logLevel
+		case "job-timeout":
+			cfg.Jobs.Timeout = *timeout
+		case "shutdown-grace":
+			cfg.Server.ShutdownGrace = *grace
+		case "auth":
+			cfg.Auth.Enabled = *authEnabled
+		case "tokens-file":
+			cfg.Auth.TokensFile = *tokensFile
+		case "audit-file":
+			cfg.Audit.File = *auditFile
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	logging.Info("Loaded configuration from %s", path)
+	return nil
+}
+
+func (c *Config) applyEnv(lookupEnv func(string) (string, bool)) error {
+	str := func(name string, dst *string) {
+		if v, ok := lookupEnv(EnvPrefix + name); ok {
+			*dst = v
+		}
+	}
+
+	str("ADDR", &c.Server.Addr)
+	str("STATIC_DIR", &c.Server.StaticDir)
+	str("JOBS_FILE", &c.Storage.JobsFile)
+	str("WORDLIST_DIR", &c.Storage.WordlistDir)
+	str("LOG_LEVEL", &c.Log.Level)
+	str("TOKENS_FILE", &c.Auth.TokensFile)
+	str("AUDIT_FILE", &c.Audit.File)
+
+	if v, ok := lookupEnv(EnvPrefix + "AUTH_ENABLED"); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%sAUTH_ENABLED: %q is not a boolean", EnvPrefix, v)
+		}
+		c.Auth.Enabled = b
+	}
+
+	if v, ok := lookupEnv(EnvPrefix + "RATE_LIMIT"); ok {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("%sRATE_LIMIT: %q is not a number", EnvPrefix, v)
+		}
+		c.Fuzzer.RateLimit = f
+	}
+
+	duration := func(name string, dst *time.Duration) error {
+		if v, ok := lookupEnv(EnvPrefix + name); ok {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("%s%s: %q is not a duration (e.g. 10s)", EnvPrefix, name, v)
+			}
+			*dst = d
+		}
+		return nil
+	}
+
+	if err := duration("JOB_TIMEOUT", &c.Jobs.Timeout); err != nil {
+		return err
+	}
+	if err := duration("SHUTDOWN_GRACE", &c.Server.ShutdownGrace); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate checks every setting and reports all problems at once
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, v ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, v...))
+	}
+
+	if _, _, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		add("server.addr %q is not a valid listen address (expected host:port or :port)", c.Server.Addr)
+	}
+	if c.Server.ShutdownGrace <= 0 {
+		add("server.shutdown_grace must be greater than 0 (got %s)", c.Server.ShutdownGrace)
+	}
+	if c.Server.StaticDir == "" {
+		add("server.static_dir must not be empty")
+	}
+	if c.Storage.JobsFile == "" {
+		add("storage.jobs_file must not be empty")
+	}
+	if c.Storage.WordlistDir == "" {
+		add("storage.wordlist_dir must not be empty")
+	}
+	if c.Fuzzer.RateLimit <= 0 {
+		add("fuzzer.rate_limit must be greater than 0 (got %v)", c.Fuzzer.RateLimit)
+	}
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		add("log.level: %v", err)
+	}
+	if c.Audit.File == "" {
+		add("audit.file must not be empty")
+	}
+	if c.Auth.Enabled && c.Auth.TokensFile == "" {
+		add("auth.tokens_file must not be empty when auth is enabled")
+	}
+	if c.Jobs.Timeout <= 0 {
+		add("jobs.timeout must be greater than 0 (got %s)", c.Jobs.Timeout)
+	} else if c.Jobs.Timeout < time.Second {
+		add("jobs.timeout must be at least 1s (got %s)", c.Jobs.Timeout)
+	}
+	for name := range c.Jobs.Headers {
+		if name == "" || strings.ContainsAny(name, " \t:\r\n") {
+			add("jobs.headers: %q is not a valid header name", name)
+		}
+	}
+	for _, code := range c.Jobs.MatchStatus {
+		if code < 100 || code > 599 {
+			add("jobs.match_status: %d is not a valid HTTP status code", code)
+		}
+	}
+
+	if _, err := scope.New(c.Scope.Allow, c.Scope.Deny); err != nil {
+		add("scope: %v", strings.ReplaceAll(err.Error(), "\n", "; "))
+	}
+	for name, project := range c.Projects {
+		if name == "" {
+			add("projects: project names must not be empty")
+		}
+		if _, err := scope.New(project.Scope.Allow, project.Scope.Deny); err != nil {
+			add("projects.%s.scope: %v", name, strings.ReplaceAll(err.Error(), "\n", "; "))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// LogLevel returns the parsed log level. It assumes Validate has passed.
+func (c *Config) LogLevel() logging.LogLevel {
+	level, _ := logging.ParseLevel(c.Log.Level)
+	return level
+}
+
+// Scopes builds the server scope and the scope of each project. It assumes
+// Validate has passed.
+func (c *Config) Scopes() (*scope.Scope, map[string]*scope.Scope, error) {
+	server, err := scope.New(c.Scope.Allow, c.Scope.Deny)
+	if err != nil {
+		return nil, nil, fmt.Errorf("scope: %w", err)
+	}
+
+	projects := make(map[string]*scope.Scope, len(c.Projects))
+	for name, project := range c.Projects {
+		s, err := scope.New(project.Scope.Allow, project.Scope.Deny)
+		if err != nil {
+			return nil, nil, fmt.Errorf("projects.%s.scope: %w", name, err)
+		}
+		projects[name] = s
+	}
+	return server, projects, nil
+}
+
+// JobOptions converts the job defaults into the form used by the fuzzer
+func (c *Config) JobOptions() types.JobOptions {
+	return types.JobOptions{
+		Timeout:     int(c.Jobs.Timeout / time.Second),
+		Headers:     c.Jobs.Headers,
+		MatchStatus: c.Jobs.MatchStatus,
+	}
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func envFrom(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load(nil, envFrom(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, ":8080", cfg.Server.Addr)
+	assert.Equal(t, "jobs.json", cfg.Storage.JobsFile)
+	assert.Equal(t, "wordlists", cfg.Storage.WordlistDir)
+	assert.Equal(t, 10.0, cfg.Fuzzer.RateLimit)
+	assert.Equal(t, 10, cfg.JobOptions().Timeout)
+
+	// Internal addresses are denied out of the box
+	server, _, err := cfg.Scopes()
+	assert.NoError(t, err)
+	for _, target := range []string{"http://127.0.0.1/", "http://[::1]/", "http://10.1.2.3/", "http://192.168.0.1/", "http://169.254.169.254/", "http://[fd00::1]/"} {
+		assert.Error(t, server.Check(target), target)
+	}
+	assert.NoError(t, server.Check("http://93.184.216.34/"))
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(path, []byte(`
+server:
+  addr: ":9000"
+fuzzer:
+  rate_limit: 5
+log:
+  level: debug
+jobs:
+  timeout: 30s
+  headers:
+    User-Agent: custom
+  match_status: [200, 301]
+`), 0644)
+	assert.NoError(t, err)
+
+	env := envFrom(map[string]string{
+		"FUZZER_CONFIG":     path,
+		"FUZZER_RATE_LIMIT": "20",
+		"FUZZER_ADDR":       ":9100",
+	})
+
+	cfg, err := Load([]string{"-addr", ":9200"}, env)
+	assert.NoError(t, err)
+
+	// Flag beats environment, environment beats file, file beats default
+	assert.Equal(t, ":9200", cfg.Server.Addr)
+	assert.Equal(t, 20.0, cfg.Fuzzer.RateLimit)
+	assert.Equal(t, "debug", cfg.Log.Level)
+	assert.Equal(t, 30*time.Second, cfg.Jobs.Timeout)
+	assert.Equal(t, "custom", cfg.Jobs.Headers["User-Agent"])
+	assert.Equal(t, []int{200, 301}, cfg.Jobs.MatchStatus)
+	assert.Equal(t, "jobs.json", cfg.Storage.JobsFile)
+}
+
+func TestLoadRejectsUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("fuzzer:\n  rate_limt: 5\n"), 0644))
+
+	_, err := Load([]string{"-config", path}, envFrom(nil))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "rate_limt")
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	cfg := Default()
+	cfg.Server.Addr = "localhost"
+	cfg.Fuzzer.RateLimit = 0
+	cfg.Log.Level = "verbose"
+	cfg.Jobs.MatchStatus = []int{200, 999}
+	cfg.Scope.Deny = []string{"10.0.0.0/99"}
+	cfg.Projects = map[string]ProjectConfig{"acme": {Scope: ScopeConfig{Allow: []string{"acme.test:http"}}}}
+
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "server.addr")
+	assert.Contains(t, err.Error(), "fuzzer.rate_limit")
+	assert.Contains(t, err.Error(), "log.level")
+	assert.Contains(t, err.Error(), "999")
+	assert.Contains(t, err.Error(), "10.0.0.0/99")
+	assert.Contains(t, err.Error(), "projects.acme.scope")
+}
+
+func TestLoadInvalidEnv(t *testing.T) {
+	_, err := Load(nil, envFrom(map[string]string{"FUZZER_JOB_TIMEOUT": "soon"}))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "FUZZER_JOB_TIMEOUT")
+}
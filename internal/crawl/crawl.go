@@ -0,0 +1,164 @@
+package crawl
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"fuzzer/internal/logging"
+	"fuzzer/types"
+)
+
+// Page is a fetched response
+type Page struct {
+	URL         string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// maxWordLength drops tokens that are more likely to be data than words
+const maxWordLength = 64
+
+// withDefaults fills unset options
+func withDefaults(o types.CrawlOptions) types.CrawlOptions {
+	if o.MaxDepth <= 0 {
+		o.MaxDepth = 2
+	}
+	if o.MaxPages <= 0 {
+		o.MaxPages = 100
+	}
+	if o.MinWordLength <= 0 {
+		o.MinWordLength = 3
+	}
+	return o
+}
+
+// Result is what a crawl found
+type Result struct {
+	// Words are ordered by how often they appeared, most frequent first
+	Words []string
+	Pages int
+}
+
+// Crawler walks a site breadth first and collects words from its pages in
+// the spirit of CeWL: visible text, link paths and parameter names, HTML and
+// JavaScript comments, and JavaScript identifiers.
+type Crawler struct {
+	opts    types.CrawlOptions
+	fetcher Fetcher
+	// allowed is consulted before every fetch, on top of the host check
+	allowed func(rawURL string) bool
+}
+
+// NewCrawler returns a crawler that fetches pages through fetcher. allowed
+// may be nil to fetch every link on the start host.
+func NewCrawler(opts types.CrawlOptions, fetcher Fetcher, allowed func(rawURL string) bool) *Crawler {
+	if allowed == nil {
+		allowed = func(string) bool { return true }
+	}
+	return &Crawler{opts: withDefaults(opts), fetcher: fetcher, allowed: allowed}
+}
+
+type queued struct {
+	url   *url.URL
+	depth int
+}
+
+// Crawl fetches start and the pages it links to. progress, if set, is
+// called with the number of pages fetched so far out of the maximum.
+func (c *Crawler) Crawl(ctx context.Context, start string, progress func(pages, max int)) (*Result, error) {
+	startURL, err := url.Parse(start)
+	if err != nil || startURL.Host == "" {
+		return nil, fmt.Errorf("invalid start URL %q", start)
+	}
+	startURL.Fragment = ""
+
+	counts := make(map[string]int)
+	seen := map[string]bool{startURL.String(): true}
+	queue := []queued{{url: startURL}}
+	pages := 0
+
+	for len(queue) > 0 && pages < c.opts.MaxPages {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		next := queue[0]
+		queue = queue[1:]
+
+		if !c.allowed(next.url.String()) {
+			continue
+		}
+		page, err := c.fetcher.Fetch(ctx, next.url.String())
+		pages++
+		if progress != nil {
+			progress(pages, c.opts.MaxPages)
+		}
+		if err != nil {
+			logging.Debug("Crawl fetch failed: %s: %v", next.url, err)
+			continue
+		}
+
+		// Words in the link itself count even if the page is an error
+		c.addWords(counts, linkWords(next.url))
+
+		switch {
+		case isHTML(page.ContentType):
+			c.addWords(counts, htmlWords(page.Body))
+			for _, link := range htmlLinks(next.url, page.Body) {
+				key := link.String()
+				c.addWords(counts, linkWords(link))
+				if seen[key] || next.depth >= c.opts.MaxDepth || !c.inScope(startURL, link) {
+					continue
+				}
+				seen[key] = true
+				queue = append(queue, queued{url: link, depth: next.depth + 1})
+			}
+		case IsScript(page.ContentType, next.url.Path):
+			c.addWords(counts, scriptWords(string(page.Body)))
+		}
+	}
+
+	return &Result{Words: rank(counts), Pages: pages}, nil
+}
+
+// inScope reports whether link is on the start host, or one of its
+// subdomains when those are included
+func (c *Crawler) inScope(start, link *url.URL) bool {
+	if link.Scheme != "http" && link.Scheme != "https" {
+		return false
+	}
+	host, startHost := link.Hostname(), start.Hostname()
+	if strings.EqualFold(host, startHost) {
+		return true
+	}
+	return c.opts.IncludeSubdomains && strings.HasSuffix(strings.ToLower(host), "."+strings.ToLower(startHost))
+}
+
+func (c *Crawler) addWords(counts map[string]int, words []string) {
+	for _, w := range words {
+		n := utf8.RuneCountInString(w)
+		if n < c.opts.MinWordLength || n > maxWordLength {
+			continue
+		}
+		counts[w]++
+	}
+}
+
+// rank orders words by count, most frequent first, then alphabetically
+func rank(counts map[string]int) []string {
+	words := make([]string, 0, len(counts))
+	for w := range counts {
+		words = append(words, w)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if counts[words[i]] != counts[words[j]] {
+			return counts[words[i]] > counts[words[j]]
+		}
+		return words[i] < words[j]
+	})
+	return words
+}
@@ -0,0 +1,216 @@
+package crawl
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"fuzzer/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// httpFetcher fetches pages with the default client
+type httpFetcher struct {
+	fetched []string
+}
+
+func (f *httpFetcher) Fetch(ctx context.Context, rawURL string) (*Page, error) {
+	f.fetched = append(f.fetched, rawURL)
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &Page{URL: rawURL, StatusCode: resp.StatusCode, ContentType: resp.Header.Get("Content-Type"), Body: body}, nil
+}
+
+func testSite(t *testing.T) *httptest.Server {
+	pages := map[string]string{
+		"/": `<html><head><title>Acme Widgets</title>
+			<meta name="description" content="Widgets &amp; gadgets">
+			<style>.hidden { color: red }</style></head>
+			<body><!-- TODO remove staging link -->
+			<a href="/about.php?team=1#top">About</a>
+			<a href="https://elsewhere.example/offsite">Offsite</a>
+			<script src="/static/app.js"></script>
+			<script>var checkoutToken = "x"; // legacy checkout</script>
+			</body></html>`,
+		"/about.php":  `<p>Meet the engineering team</p><a href="/deep/level">deeper</a>`,
+		"/deep/level": `<p>unreachable</p>`,
+		"/static/app.js": `/* Internal API client */
+			function fetchInvoices(customerId) { return apiRequest("/api/invoices"); }`,
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, ".js") {
+			w.Header().Set("Content-Type", "application/javascript")
+		} else {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		}
+		fmt.Fprint(w, body)
+	}))
+}
+
+func TestCrawlCollectsWords(t *testing.T) {
+	server := testSite(t)
+	defer server.Close()
+
+	fetcher := &httpFetcher{}
+	crawler := NewCrawler(types.CrawlOptions{MaxDepth: 1, MinWordLength: 4}, fetcher, nil)
+	result, err := crawler.Crawl(context.Background(), server.URL+"/", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.Pages)
+	assert.ElementsMatch(t, []string{server.URL + "/", server.URL + "/about.php?team=1", server.URL + "/static/app.js"}, fetcher.fetched)
+
+	for _, want := range []string{
+		"Acme", "Widgets", "gadgets", // title and meta
+		"TODO", "staging", // HTML comment
+		"about", "about.php", "team", // link path and parameter
+		"checkoutToken", "legacy", // inline script
+		"fetchInvoices", "customerId", "Internal", "invoices", // external script
+		"engineering", "offsite", // second page, and the offsite link's path
+	} {
+		assert.Contains(t, result.Words, want)
+	}
+	for _, unwanted := range []string{
+		"function", "return", "var", // JavaScript keywords
+		"hidden", "color", // styles
+		"the", "red", // shorter than the minimum
+		"unreachable", // past the maximum depth
+	} {
+		assert.NotContains(t, result.Words, unwanted)
+	}
+}
+
+func TestCrawlRanksByFrequency(t *testing.T) {
+	counts := map[string]int{"beta": 1, "alpha": 1, "gamma": 3}
+	assert.Equal(t, []string{"gamma", "alpha", "beta"}, rank(counts))
+}
+
+func TestCrawlScope(t *testing.T) {
+	start, _ := url.Parse("http://example.com/")
+	sub, _ := url.Parse("https://api.example.com/v1")
+	other, _ := url.Parse("http://notexample.com/")
+	mail, _ := url.Parse("mailto:admin@example.com")
+
+	c := NewCrawler(types.CrawlOptions{}, nil, nil)
+	assert.False(t, c.inScope(start, sub))
+	assert.False(t, c.inScope(start, mail))
+
+	c = NewCrawler(types.CrawlOptions{IncludeSubdomains: true}, nil, nil)
+	assert.True(t, c.inScope(start, sub))
+	assert.False(t, c.inScope(start, other))
+
+	server := testSite(t)
+	defer server.Close()
+	fetcher := &httpFetcher{}
+	c = NewCrawler(types.CrawlOptions{MaxPages: 1}, fetcher, nil)
+	result, err := c.Crawl(context.Background(), server.URL, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Pages)
+
+	fetcher = &httpFetcher{}
+	c = NewCrawler(types.CrawlOptions{}, fetcher, func(rawURL string) bool {
+		return !strings.Contains(rawURL, "about")
+	})
+	_, err = c.Crawl(context.Background(), server.URL, nil)
+	assert.NoError(t, err)
+	for _, u := range fetcher.fetched {
+		assert.NotContains(t, u, "about")
+	}
+}
+
+func TestScriptLinks(t *testing.T) {
+	assert.True(t, IsScript("application/javascript; charset=utf-8", "/bundle"))
+	assert.True(t, IsScript("", "/static/app.JS"))
+	assert.True(t, IsScript("text/plain", "/module.mjs"))
+	assert.False(t, IsScript("text/html", "/index.html"))
+
+	for _, link := range []string{"/api/v1/users", "./views/list.html", "admin/settings", "users/:id", "api/orders?page=1", "https://cdn.example.com/x.js"} {
+		assert.True(t, IsScriptLink(link), link)
+	}
+	for _, notLink := range []string{"/", "//cdn", "hello", "application/json", "12/05/2020", "a b/c"} {
+		assert.False(t, IsScriptLink(notLink), notLink)
+	}
+
+	base, _ := url.Parse("http://example.com/static/app.js")
+	var got []string
+	for _, u := range Links(base, "application/javascript", []byte(`fetch('/api/items'); const t = "text/html"; load("views/list.html")`)) {
+		got = append(got, u.String())
+	}
+	assert.Equal(t, []string{"http://example.com/api/items", "http://example.com/static/views/list.html"}, got)
+}
+
+func TestSeeds(t *testing.T) {
+	files := map[string]string{
+		"/robots.txt": "User-agent: *\nDisallow: /app/private/ # keep out\nDisallow: /app/exports/*.csv\nAllow: /app/public\nDisallow: /elsewhere/\nDisallow: /\nSitemap: SERVER/sitemap_index.xml\n",
+		"/sitemap_index.xml": `<?xml version="1.0"?><sitemapindex>
+			<sitemap><loc>SERVER/sitemap-pages.xml</loc></sitemap>
+			<sitemap><loc>SERVER/sitemap-old.xml.gz</loc></sitemap>
+			</sitemapindex>`,
+		"/sitemap-pages.xml":        `<urlset><url><loc> SERVER/app/about?ref=a&amp;b=c </loc></url><url><loc>https://cdn.example/app/x</loc></url></urlset>`,
+		"/sitemap-old.xml.gz":       `<urlset><url><loc>SERVER/app/archive/2023/</loc></url></urlset>`,
+		"/.well-known/security.txt": "Contact: mailto:security@example.com\nPolicy: SERVER/app/security-policy\n",
+	}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		body = strings.ReplaceAll(body, "SERVER", server.URL)
+		if strings.HasSuffix(r.URL.Path, ".gz") {
+			zw := gzip.NewWriter(w)
+			zw.Write([]byte(body))
+			zw.Close()
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	seeds, err := Seeds(context.Background(), &httpFetcher{}, server.URL+"/app", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []Seed{
+		{Path: "private/", From: "robots.txt"},
+		{Path: "exports/", From: "robots.txt"},
+		{Path: "public", From: "robots.txt"},
+		{Path: "about", From: "sitemap-pages.xml"},
+		{Path: "archive/2023/", From: "sitemap-old.xml.gz"},
+		{Path: "security-policy", From: "security.txt"},
+	}, seeds)
+
+	// The files themselves are seeds when the target is the site's root
+	seeds, err = Seeds(context.Background(), &httpFetcher{}, server.URL, func(rawURL string) bool {
+		return !strings.Contains(rawURL, "security")
+	})
+	assert.NoError(t, err)
+	var paths []string
+	for _, s := range seeds {
+		paths = append(paths, s.Path)
+	}
+	assert.Contains(t, paths, "robots.txt")
+	assert.Contains(t, paths, "sitemap_index.xml")
+	assert.Contains(t, paths, "elsewhere/")
+	assert.NotContains(t, paths, ".well-known/security.txt")
+}
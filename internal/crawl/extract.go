@@ -0,0 +1,227 @@
+package crawl
+
+import (
+	"html"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	htmlCommentRe = regexp.MustCompile(`(?s)<!--(.*?)-->`)
+	scriptRe      = regexp.MustCompile(`(?is)<script\b[^>]*>(.*?)</script\s*>`)
+	styleRe       = regexp.MustCompile(`(?is)<style\b[^>]*>.*?</style\s*>`)
+	tagRe         = regexp.MustCompile(`(?s)<[^>]*>`)
+	linkAttrRe    = regexp.MustCompile(`(?i)\b(?:href|src|action)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	metaContentRe = regexp.MustCompile(`(?i)<meta\b[^>]*\bcontent\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	jsCommentRe   = regexp.MustCompile(`(?s)/\*(.*?)\*/|(?m)(?:^|[^:\\])//([^\n]*)`)
+	identRe       = regexp.MustCompile(`[A-Za-z_$][A-Za-z0-9_$]*`)
+	wordRe        = regexp.MustCompile(`[\p{L}\p{N}][\p{L}\p{N}_-]*[\p{L}\p{N}]|[\p{L}\p{N}]`)
+	// scriptStringRe matches the string literals of a script on a single
+	// line, in any of its quotes
+	scriptStringRe = regexp.MustCompile("\"((?:[^\"\\\\\\n]|\\\\.){2,})\"|'((?:[^'\\\\\\n]|\\\\.){2,})'|`([^`]{2,})`")
+	// absoluteURLRe matches absolute http and https URLs
+	absoluteURLRe = regexp.MustCompile(`^https?://[A-Za-z0-9.-]+(?::\d+)?(?:/[^\s"'<>]*)?$`)
+	// scriptPathRe matches rooted and relative paths such as /api/users,
+	// ./views/list.html and api/v1/orders?page=1, with route parameters
+	// such as :id and {id} after the first segment
+	scriptPathRe = regexp.MustCompile(`^(?:\.{0,2}/)?[A-Za-z0-9_~-][A-Za-z0-9_.~%-]*(?:/[A-Za-z0-9_.~%{}:-]+)*/?(?:\?[^\s"'<>]*)?$`)
+)
+
+// mediaTypePrefixes start strings that look like relative paths but are
+// media types
+var mediaTypePrefixes = []string{"application/", "audio/", "font/", "image/", "multipart/", "text/", "video/", "model/", "message/"}
+
+// jsKeywords are left out of the identifiers taken from scripts, as they say
+// nothing about the site
+var jsKeywords = map[string]bool{
+	"async": true, "await": true, "break": true, "case": true, "catch": true,
+	"class": true, "const": true, "continue": true, "debugger": true,
+	"default": true, "delete": true, "document": true, "else": true,
+	"export": true, "extends": true, "false": true, "finally": true,
+	"for": true, "function": true, "if": true, "import": true, "in": true,
+	"instanceof": true, "let": true, "new": true, "null": true, "return": true,
+	"static": true, "super": true, "switch": true, "this": true, "throw": true,
+	"true": true, "try": true, "typeof": true, "undefined": true, "var": true,
+	"void": true, "while": true, "window": true, "with": true, "yield": true,
+}
+
+func isHTML(contentType string) bool {
+	ct := strings.ToLower(contentType)
+	return ct == "" || strings.Contains(ct, "text/html") || strings.Contains(ct, "xhtml")
+}
+
+// IsScript reports whether a response is JavaScript, by its content type
+// or the extension of its path
+func IsScript(contentType, path string) bool {
+	ct := strings.ToLower(contentType)
+	if strings.Contains(ct, "javascript") || strings.Contains(ct, "ecmascript") {
+		return true
+	}
+	path = strings.ToLower(path)
+	return strings.HasSuffix(path, ".js") || strings.HasSuffix(path, ".mjs")
+}
+
+// ScriptString is a string literal of a script and the offset its content
+// starts at
+type ScriptString struct {
+	Value  string
+	Offset int
+}
+
+// ScriptStrings returns the string literals of a script, in order
+func ScriptStrings(src string) []ScriptString {
+	var strs []ScriptString
+	for _, m := range scriptStringRe.FindAllStringSubmatchIndex(src, -1) {
+		// Whichever quote matched has its group set
+		for i := 2; i+1 < len(m); i += 2 {
+			if m[i] >= 0 {
+				strs = append(strs, ScriptString{Value: src[m[i]:m[i+1]], Offset: m[i]})
+				break
+			}
+		}
+	}
+	return strs
+}
+
+// IsScriptLink reports whether a script's string literal is a URL or looks
+// like a path. Relative paths need a slash that isn't at either end and
+// mustn't be media types, so words and content types aren't taken for
+// routes.
+func IsScriptLink(value string) bool {
+	if absoluteURLRe.MatchString(value) {
+		return true
+	}
+	if !scriptPathRe.MatchString(value) || strings.HasPrefix(value, "//") {
+		return false
+	}
+	if !strings.HasPrefix(value, "/") && !strings.Contains(strings.Trim(value, "/"), "/") {
+		return false
+	}
+	if !strings.ContainsFunc(value, unicode.IsLetter) {
+		return false
+	}
+	for _, prefix := range mediaTypePrefixes {
+		if strings.HasPrefix(value, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// htmlWords returns the words of a page's visible text, meta content,
+// comments and inline scripts
+func htmlWords(body []byte) []string {
+	doc := string(body)
+	var words []string
+
+	for _, m := range htmlCommentRe.FindAllStringSubmatch(doc, -1) {
+		words = append(words, textWords(m[1])...)
+	}
+	for _, m := range scriptRe.FindAllStringSubmatch(doc, -1) {
+		words = append(words, scriptWords(m[1])...)
+	}
+	for _, m := range metaContentRe.FindAllStringSubmatch(doc, -1) {
+		words = append(words, textWords(html.UnescapeString(m[1]+m[2]))...)
+	}
+
+	text := htmlCommentRe.ReplaceAllString(doc, " ")
+	text = scriptRe.ReplaceAllString(text, " ")
+	text = styleRe.ReplaceAllString(text, " ")
+	text = tagRe.ReplaceAllString(text, " ")
+	return append(words, textWords(html.UnescapeString(text))...)
+}
+
+// htmlLinks returns the links of a page resolved against its URL, without
+// fragments
+func htmlLinks(base *url.URL, body []byte) []*url.URL {
+	var links []*url.URL
+	for _, m := range linkAttrRe.FindAllSubmatch(body, -1) {
+		ref := html.UnescapeString(strings.TrimSpace(string(m[1]) + string(m[2]) + string(m[3])))
+		if ref == "" || strings.HasPrefix(ref, "#") {
+			continue
+		}
+		u, err := base.Parse(ref)
+		if err != nil {
+			continue
+		}
+		u.Fragment = ""
+		links = append(links, u)
+	}
+	return links
+}
+
+// scriptLinks returns the string literals of a script that are links,
+// resolved against base
+func scriptLinks(base *url.URL, src string) []*url.URL {
+	var links []*url.URL
+	for _, str := range ScriptStrings(src) {
+		if !IsScriptLink(str.Value) {
+			continue
+		}
+		u, err := base.Parse(str.Value)
+		if err != nil {
+			continue
+		}
+		u.Fragment = ""
+		links = append(links, u)
+	}
+	return links
+}
+
+// Links returns the links in a response resolved against its URL: the
+// href, src and action attributes of a page and the path-like string
+// literals of its inline scripts, or those of a script
+func Links(base *url.URL, contentType string, body []byte) []*url.URL {
+	switch {
+	case IsScript(contentType, base.Path):
+		return scriptLinks(base, string(body))
+	case isHTML(contentType):
+		links := htmlLinks(base, body)
+		for _, m := range scriptRe.FindAllSubmatch(body, -1) {
+			links = append(links, scriptLinks(base, string(m[1]))...)
+		}
+		return links
+	}
+	return nil
+}
+
+// linkWords returns the path segments of a link, with and without their
+// extension, and its query parameter names
+func linkWords(u *url.URL) []string {
+	var words []string
+	for _, seg := range strings.Split(u.Path, "/") {
+		if seg == "" {
+			continue
+		}
+		words = append(words, seg)
+		if ext := path.Ext(seg); ext != "" && ext != seg {
+			words = append(words, strings.TrimSuffix(seg, ext))
+		}
+	}
+	for name := range u.Query() {
+		words = append(words, name)
+	}
+	return words
+}
+
+// scriptWords returns the identifiers and comment text of a script
+func scriptWords(src string) []string {
+	var words []string
+	for _, m := range jsCommentRe.FindAllStringSubmatch(src, -1) {
+		words = append(words, textWords(m[1]+m[2])...)
+	}
+	for _, ident := range identRe.FindAllString(jsCommentRe.ReplaceAllString(src, " "), -1) {
+		if !jsKeywords[ident] {
+			words = append(words, ident)
+		}
+	}
+	return words
+}
+
+// textWords splits prose into words
+func textWords(text string) []string {
+	return wordRe.FindAllString(text, -1)
+}
@@ -0,0 +1,9 @@
+package crawl
+
+import "context"
+
+// Fetcher retrieves a page for the crawler. Requests are expected to be rate
+// limited and scope checked by the implementation.
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) (*Page, error)
+}
@@ -0,0 +1,216 @@
+package crawl
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"fuzzer/internal/logging"
+)
+
+const (
+	// maxSitemaps caps how many sitemaps are read, counting those listed
+	// in sitemap indexes
+	maxSitemaps = 20
+	// maxSeeds caps how many paths seeding returns
+	maxSeeds = 1000
+	// maxSitemapSize caps a gzipped sitemap once decompressed
+	maxSitemapSize = 10 << 20
+)
+
+// Seed is a path found in a site's own description of itself
+type Seed struct {
+	// Path is relative to the target, without a leading slash
+	Path string
+	// From names the file the path was found in
+	From string
+}
+
+var (
+	sitemapLoc = regexp.MustCompile(`(?is)<loc>\s*(.*?)\s*</loc>`)
+	textURL    = regexp.MustCompile(`https?://[^\s<>"']+`)
+)
+
+// seeder collects the seeds of one target
+type seeder struct {
+	fetcher Fetcher
+	allowed func(rawURL string) bool
+	target  *url.URL
+	seeds   []Seed
+	seen    map[string]bool
+}
+
+// Seeds reads robots.txt, the sitemaps it names or /sitemap.xml, sitemap
+// indexes and gzipped sitemaps, and security.txt, and returns the paths
+// they mention on the target's host, under its path. The files that exist
+// are seeds too. allowed may be nil to fetch anything on the host.
+func Seeds(ctx context.Context, fetcher Fetcher, target string, allowed func(rawURL string) bool) ([]Seed, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid target %q", target)
+	}
+	if allowed == nil {
+		allowed = func(string) bool { return true }
+	}
+	s := &seeder{fetcher: fetcher, allowed: allowed, target: u, seen: make(map[string]bool)}
+
+	sitemaps := s.robots(ctx)
+	if len(sitemaps) == 0 {
+		sitemaps = []string{s.root("/sitemap.xml")}
+	}
+	s.sitemaps(ctx, sitemaps)
+	s.securityTxt(ctx)
+	return s.seeds, ctx.Err()
+}
+
+// root resolves an absolute path against the target's host
+func (s *seeder) root(path string) string {
+	return (&url.URL{Scheme: s.target.Scheme, Host: s.target.Host, Path: path}).String()
+}
+
+// fetch gets a file, returning nil unless it was found
+func (s *seeder) fetch(ctx context.Context, rawURL string) *Page {
+	if ctx.Err() != nil || !s.allowed(rawURL) {
+		return nil
+	}
+	page, err := s.fetcher.Fetch(ctx, rawURL)
+	if err != nil {
+		logging.Debug("Seed fetch failed: %s: %v", rawURL, err)
+		return nil
+	}
+	if page.StatusCode != http.StatusOK {
+		return nil
+	}
+	return page
+}
+
+// add records a URL or absolute path on the target's host as a seed
+func (s *seeder) add(ref, from string) {
+	if len(s.seeds) >= maxSeeds {
+		return
+	}
+	u, err := s.target.Parse(ref)
+	if err != nil || u.Host != s.target.Host {
+		return
+	}
+	base := strings.TrimSuffix(s.target.Path, "/") + "/"
+	if !strings.HasPrefix(u.EscapedPath(), base) {
+		return
+	}
+	path := strings.TrimPrefix(u.EscapedPath(), base)
+	if path == "" || s.seen[path] {
+		return
+	}
+	s.seen[path] = true
+	s.seeds = append(s.seeds, Seed{Path: path, From: from})
+}
+
+// robots seeds the Allow and Disallow paths of robots.txt and returns the
+// sitemaps it lists
+func (s *seeder) robots(ctx context.Context) []string {
+	page := s.fetch(ctx, s.root("/robots.txt"))
+	if page == nil {
+		return nil
+	}
+	s.add("/robots.txt", "robots.txt")
+
+	var sitemaps []string
+	scanner := bufio.NewScanner(bytes.NewReader(page.Body))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		field, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(field)) {
+		case "allow", "disallow":
+			// Wildcards can't be requested, so keep the directory before them
+			if i := strings.IndexAny(value, "*$"); i >= 0 {
+				value = value[:strings.LastIndex(value[:i], "/")+1]
+			}
+			if strings.HasPrefix(value, "/") {
+				s.add(value, "robots.txt")
+			}
+		case "sitemap":
+			sitemaps = append(sitemaps, value)
+		}
+	}
+	return sitemaps
+}
+
+// sitemaps reads sitemaps breadth first, following sitemap indexes, and
+// seeds the pages they list
+func (s *seeder) sitemaps(ctx context.Context, queue []string) {
+	read := make(map[string]bool)
+	for len(queue) > 0 && len(read) < maxSitemaps {
+		next := queue[0]
+		queue = queue[1:]
+		if read[next] {
+			continue
+		}
+		read[next] = true
+
+		page := s.fetch(ctx, next)
+		if page == nil {
+			continue
+		}
+		body, err := gunzip(page.Body)
+		if err != nil {
+			logging.Debug("Failed to decompress sitemap %s: %v", next, err)
+			continue
+		}
+		name := "sitemap"
+		if u, err := url.Parse(next); err == nil {
+			name = strings.TrimPrefix(u.Path, "/")
+		}
+		s.add(next, name)
+
+		index := bytes.Contains(body, []byte("<sitemapindex"))
+		for _, m := range sitemapLoc.FindAllSubmatch(body, -1) {
+			loc := html.UnescapeString(string(m[1]))
+			if index {
+				queue = append(queue, loc)
+				continue
+			}
+			s.add(loc, name)
+		}
+	}
+}
+
+// gunzip decompresses body if it is gzipped
+func gunzip(body []byte) ([]byte, error) {
+	if !bytes.HasPrefix(body, []byte("\x1f\x8b")) {
+		return body, nil
+	}
+	r, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(io.LimitReader(r, maxSitemapSize))
+}
+
+// securityTxt seeds security.txt from either of its locations, and the
+// pages on the host that it links to such as the disclosure policy
+func (s *seeder) securityTxt(ctx context.Context) {
+	for _, path := range []string{"/.well-known/security.txt", "/security.txt"} {
+		page := s.fetch(ctx, s.root(path))
+		if page == nil || !bytes.Contains(bytes.ToLower(page.Body), []byte("contact:")) {
+			continue
+		}
+		s.add(path, "security.txt")
+		for _, link := range textURL.FindAll(page.Body, -1) {
+			s.add(string(link), "security.txt")
+		}
+		return
+	}
+}
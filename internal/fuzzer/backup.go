@@ -0,0 +1,234 @@
+package fuzzer
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"fuzzer/internal/logging"
+	"fuzzer/types"
+)
+
+// leftover is a file a known path may have left behind, and how to tell a
+// real one from an error page
+type leftover struct {
+	path string
+	// kind describes the file in findings
+	kind string
+	// signature confirms the content. Files without one are confirmed by
+	// comparing them with a file that can't exist.
+	signature func(body []byte) bool
+}
+
+// missingPrefix is put before a leftover's name to ask for a file that
+// can't exist, to see how the server answers for missing files
+const missingPrefix = "fzb4kmissing-"
+
+// backupSuffixes are appended to a file by editors, deploys and people
+// making a quick copy
+var backupSuffixes = []string{".bak", "~", ".orig", ".old", ".save"}
+
+// archiveSignatures are the magic bytes of the archive formats looked for
+var archiveSignatures = []struct {
+	ext       string
+	signature func(body []byte) bool
+}{
+	// A local file header, or the end of central directory record an
+	// empty archive starts with, or the marker of a spanned one
+	{".zip", hasPrefix("PK\x03\x04", "PK\x05\x06", "PK\x07\x08")},
+	{".tar.gz", hasPrefix("\x1f\x8b")},
+	{".tgz", hasPrefix("\x1f\x8b")},
+	{".tar", func(b []byte) bool { return len(b) > 262 && string(b[257:262]) == "ustar" }},
+	{".rar", hasPrefix("Rar!\x1a\x07")},
+	{".7z", hasPrefix("7z\xbc\xaf\x27\x1c")},
+}
+
+var (
+	gitHead = regexp.MustCompile(`^(ref: refs/\S+|[0-9a-f]{40})\s*$`)
+	envLine = regexp.MustCompile(`(?m)^[A-Za-z_][A-Za-z0-9_]*[ \t]*=`)
+)
+
+// hasPrefix returns a signature matching content that starts with any of
+// the magic strings
+func hasPrefix(magics ...string) func([]byte) bool {
+	return func(body []byte) bool {
+		for _, magic := range magics {
+			if bytes.HasPrefix(body, []byte(magic)) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func isGitHead(body []byte) bool {
+	return gitHead.Match(body)
+}
+
+// isEnvFile reports whether body holds KEY=value lines and isn't a page
+func isEnvFile(body []byte) bool {
+	head := bytes.ToLower(body[:min(len(body), 512)])
+	if bytes.Contains(head, []byte("<html")) || bytes.Contains(head, []byte("<!doctype")) {
+		return false
+	}
+	return envLine.Match(body)
+}
+
+// leftovers returns what to look for around a payload. Paths with an
+// extension are files, whose backups and swap files are tried. Anything
+// else is a directory, which is checked for version control, environment
+// and Finder files and for archives named after the host or itself.
+func leftovers(host, payload string) []leftover {
+	p := strings.Trim(payload, "/")
+	var list []leftover
+
+	if p != "" && !strings.HasSuffix(payload, "/") && path.Ext(p) != "" {
+		for _, suffix := range backupSuffixes {
+			list = append(list, leftover{path: p + suffix, kind: "backup of " + p})
+		}
+		dir, name := path.Split(p)
+		swap := hasPrefix("b0VIM")
+		list = append(list,
+			leftover{path: dir + "." + name + ".swp", kind: "vim swap file of " + p, signature: swap},
+			leftover{path: p + ".swp", kind: "vim swap file of " + p, signature: swap},
+		)
+		return list
+	}
+
+	dir := p
+	if dir != "" {
+		dir += "/"
+	}
+	list = append(list,
+		leftover{path: dir + ".git/HEAD", kind: "git repository", signature: isGitHead},
+		leftover{path: dir + ".env", kind: "environment file", signature: isEnvFile},
+		leftover{path: dir + ".DS_Store", kind: "Finder metadata", signature: hasPrefix("\x00\x00\x00\x01Bud1")},
+	)
+
+	// Archives named after the host, as in example.com.zip, and after the
+	// directory itself, next to it
+	names := []string{host}
+	if net.ParseIP(host) == nil {
+		if bare := strings.TrimPrefix(host, "www."); bare != host {
+			names = append(names, bare)
+		}
+		if label, _, found := strings.Cut(strings.TrimPrefix(host, "www."), "."); found {
+			names = append(names, label)
+		}
+	}
+	var archives []string
+	for _, name := range names {
+		archives = append(archives, dir+name)
+	}
+	if p != "" {
+		archives = append(archives, p)
+	}
+	for _, archive := range archives {
+		for _, a := range archiveSignatures {
+			list = append(list, leftover{path: archive + a.ext, kind: a.ext[1:] + " archive", signature: a.signature})
+		}
+	}
+	return list
+}
+
+// backupType takes known paths, such as a directory scan's findings, and
+// looks for the backups and sensitive files they may have left behind
+type backupType struct{}
+
+func (backupType) Validate(job *types.Job) error {
+	if _, err := parseTarget(job); err != nil {
+		return err
+	}
+	return nil
+}
+
+// BuildRequests returns a request for each of the payload's leftovers
+func (backupType) BuildRequests(ctx context.Context, job *types.Job, payload string) ([]*http.Request, error) {
+	target, err := url.Parse(job.Target)
+	if err != nil {
+		return nil, err
+	}
+	var reqs []*http.Request
+	for _, l := range leftovers(target.Hostname(), payload) {
+		built, err := directoryType{}.BuildRequests(ctx, job, l.path)
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, built...)
+	}
+	return reqs, nil
+}
+
+// Judge isn't called, as leftovers are confirmed by their content
+func (backupType) Judge(job *types.Job, resp *Response) (string, bool) {
+	return resp.Request.URL.String(), false
+}
+
+// Probe requests each leftover and reports those answered with content
+// that is really what was asked for, so error pages served with 200 aren't
+func (b backupType) Probe(ctx context.Context, job *types.Job, payload string, send SendFunc) []Hit {
+	reqs, err := b.BuildRequests(ctx, job, payload)
+	if err != nil {
+		logging.Debug("Failed to build requests for %q: %v", payload, err)
+		return nil
+	}
+	target, _ := url.Parse(job.Target)
+	list := leftovers(target.Hostname(), payload)
+
+	var hits []Hit
+	for i, req := range reqs {
+		resp, err := send(req)
+		if err != nil {
+			logging.Debug("Request failed: %s: %v", req.URL, err)
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			continue
+		}
+		l := list[i]
+		if l.signature != nil {
+			if !l.signature(resp.Body) {
+				continue
+			}
+		} else if b.softNotFound(ctx, job, l, resp, send) {
+			continue
+		}
+		hits = append(hits, Hit{Response: resp, Finding: types.Finding{URL: req.URL.String(), Detail: l.kind}})
+	}
+	return hits
+}
+
+// softNotFound reports whether a response for a leftover without a
+// signature looks like what the server sends for a file that can't exist
+// with the same name pattern
+func (backupType) softNotFound(ctx context.Context, job *types.Job, l leftover, resp *Response, send SendFunc) bool {
+	dir, name := path.Split(l.path)
+	reqs, err := directoryType{}.BuildRequests(ctx, job, dir+missingPrefix+name)
+	if err != nil {
+		return true
+	}
+	missing, err := send(reqs[0])
+	if err != nil {
+		logging.Debug("Soft 404 check failed for %s: %v", l.path, err)
+		return true
+	}
+	if missing.StatusCode != http.StatusOK {
+		return false
+	}
+	// Error pages often repeat the path they were asked for
+	a := bytes.ReplaceAll(resp.Body, []byte(name), nil)
+	b := bytes.ReplaceAll(missing.Body, []byte(missingPrefix+name), nil)
+	if bytes.Equal(a, b) {
+		return true
+	}
+	diff := len(a) - len(b)
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= max(len(a), len(b))/10
+}
@@ -0,0 +1,169 @@
+package fuzzer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"fuzzer/internal/logging"
+	"fuzzer/types"
+)
+
+// BypassType is the finding type of a request that got past a 403
+const BypassType = "bypass"
+
+// bypass is one trick for getting past a 403. Set fields replace the
+// forbidden request's.
+type bypass struct {
+	path   string
+	method string
+	header string
+	value  string
+	detail string
+	// rewrite marks tricks that request another path and name the
+	// forbidden one in a header. They only count if the response differs
+	// from that path's own.
+	rewrite bool
+}
+
+// ipHeaders are the headers proxies use to pass on the client's address,
+// which some access controls trust
+var ipHeaders = []string{"X-Forwarded-For", "X-Real-IP", "X-Originating-IP", "X-Client-IP", "X-Custom-IP-Authorization"}
+
+// bypassMethods are tried in place of the forbidden request's method
+var bypassMethods = []string{"POST", "PUT", "PATCH"}
+
+// bypasses returns the tricks to try on a forbidden escaped path
+func bypasses(path string) []bypass {
+	var list []bypass
+	trimmed := strings.TrimSuffix(path, "/")
+	if trimmed != "" {
+		// LastIndex is -1 for a path without a slash, leaving dir empty
+		slash := strings.LastIndex(trimmed, "/")
+		dir, last := trimmed[:max(slash, 0)], trimmed[slash+1:]
+		variants := []string{
+			trimmed + "/",
+			trimmed,
+			"/." + trimmed,
+			trimmed + "/.",
+			"/" + trimmed,
+			"/%2e" + trimmed,
+			dir + "/%2e/" + last,
+			trimmed + "%20",
+			trimmed + "%09",
+			trimmed + ";/",
+			trimmed + "..;/",
+			trimmed + "/..;/",
+		}
+		// Paths such as "//" end in an empty segment, which has no first
+		// letter to encode or case to change
+		if last != "" {
+			variants = append(variants,
+				fmt.Sprintf("%s/%%%02x%s", dir, last[0], last[1:]),
+				dir+"/"+strings.ToUpper(last),
+				dir+"/"+strings.ToUpper(last[:1])+last[1:],
+			)
+		}
+		seen := map[string]bool{path: true}
+		for _, v := range variants {
+			if !seen[v] {
+				seen[v] = true
+				list = append(list, bypass{path: v, detail: "path " + v})
+			}
+		}
+		// Some front ends authorize the request line but route on these
+		for _, header := range []string{"X-Original-URL", "X-Rewrite-URL"} {
+			list = append(list, bypass{path: "/", header: header, value: path, detail: header + ": " + path, rewrite: true})
+		}
+	}
+	for _, header := range ipHeaders {
+		list = append(list, bypass{header: header, value: "127.0.0.1", detail: header + ": 127.0.0.1"})
+	}
+	for _, method := range bypassMethods {
+		list = append(list, bypass{method: method, detail: "method " + method})
+	}
+	return list
+}
+
+// bypassRequest applies a bypass to the forbidden request, leaving out its
+// header if withHeader is false
+func bypassRequest(ctx context.Context, forbidden *http.Request, b bypass, withHeader bool) (*http.Request, error) {
+	req := forbidden.Clone(ctx)
+	if b.path != "" {
+		path, err := url.PathUnescape(b.path)
+		if err != nil {
+			return nil, err
+		}
+		req.URL.Path, req.URL.RawPath = path, b.path
+	}
+	if b.method != "" {
+		req.Method = b.method
+	}
+	if b.header != "" && withHeader {
+		req.Header.Set(b.header, b.value)
+	}
+	return req, nil
+}
+
+// bypassForbidden tries each bypass on the request behind a 403 finding and
+// returns a finding linked to it for every one answered with a 2xx. Rewrite
+// tricks are compared with the path they request, so a home page answering
+// 200 isn't taken for a bypass.
+func bypassForbidden(ctx context.Context, forbidden string, resp *Response, send SendFunc) []types.Finding {
+	var findings []types.Finding
+	baselines := make(map[string]*fingerprint)
+	for _, b := range bypasses(resp.Request.URL.EscapedPath()) {
+		if ctx.Err() != nil {
+			break
+		}
+		req, err := bypassRequest(ctx, resp.Request, b, true)
+		if err != nil {
+			continue
+		}
+
+		got, err := send(req)
+		if err != nil {
+			logging.Debug("Bypass request failed: %s: %v", b.detail, err)
+			continue
+		}
+		if got.StatusCode < http.StatusOK || got.StatusCode >= http.StatusMultipleChoices {
+			continue
+		}
+		if b.rewrite {
+			base, ok := baselines[b.path]
+			if !ok {
+				base = bypassBaseline(ctx, resp.Request, b, send)
+				baselines[b.path] = base
+			}
+			if base == nil || *base == fingerprintOf(got) {
+				continue
+			}
+		}
+		findings = append(findings, types.Finding{
+			URL:    req.URL.String(),
+			Type:   BypassType,
+			Method: req.Method,
+			Detail: fmt.Sprintf("%s returned %d", b.detail, got.StatusCode),
+			Parent: forbidden,
+		})
+	}
+	return findings
+}
+
+// bypassBaseline fingerprints the response to a rewrite trick's request
+// without its header, or returns nil if it can't be had
+func bypassBaseline(ctx context.Context, forbidden *http.Request, b bypass, send SendFunc) *fingerprint {
+	req, err := bypassRequest(ctx, forbidden, b, false)
+	if err != nil {
+		return nil
+	}
+	resp, err := send(req)
+	if err != nil {
+		logging.Debug("Bypass baseline failed: %s: %v", b.detail, err)
+		return nil
+	}
+	base := fingerprintOf(resp)
+	return &base
+}
@@ -0,0 +1,126 @@
+package fuzzer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"fuzzer/internal/crawl"
+	"fuzzer/internal/logging"
+	"fuzzer/types"
+)
+
+// maxCrawlBody caps how much of each page a crawl reads
+const maxCrawlBody = 2 << 20
+
+// crawlFetcher fetches pages for a crawl job with the job's headers and
+// timeout, under the manager's rate limit
+type crawlFetcher struct {
+	m   *Manager
+	job *types.Job
+}
+
+func (f *crawlFetcher) Fetch(ctx context.Context, rawURL string) (*crawl.Page, error) {
+	if err := f.m.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	applyHeaders(req, f.job)
+
+	resp, err := f.m.clientFor(f.job, crawlType{}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCrawlBody))
+	if err != nil {
+		return nil, err
+	}
+	return &crawl.Page{
+		URL:         rawURL,
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        body,
+	}, nil
+}
+
+// crawlType builds a wordlist from the target's content rather than
+// fuzzing it with one
+type crawlType struct{}
+
+func (crawlType) Validate(job *types.Job) error {
+	if _, err := parseTarget(job); err != nil {
+		return err
+	}
+	// Crawls collect words rather than findings, so there is nothing for
+	// an expression to judge
+	if strings.TrimSpace(job.Options.Match) != "" {
+		return fmt.Errorf("%w: crawl jobs don't support match expressions", types.ErrInvalidJob)
+	}
+	return nil
+}
+
+func (crawlType) BuildRequests(ctx context.Context, job *types.Job, payload string) ([]*http.Request, error) {
+	return nil, nil
+}
+
+func (crawlType) Judge(job *types.Job, resp *Response) (string, bool) {
+	return "", false
+}
+
+// Run crawls the job's target and saves the words it finds as a new
+// wordlist, whose ID is recorded as the job's wordlist
+func (crawlType) Run(ctx context.Context, m *Manager, job *types.Job) error {
+	var opts types.CrawlOptions
+	if job.Options.Crawl != nil {
+		opts = *job.Options.Crawl
+	}
+	crawler := crawl.NewCrawler(opts, &crawlFetcher{m: m, job: job}, func(rawURL string) bool {
+		return m.allowed(job, rawURL)
+	})
+
+	result, err := crawler.Crawl(ctx, job.Target, func(pages, max int) {
+		job.Progress = min(100, pages*100/max)
+	})
+	if err != nil {
+		return fmt.Errorf("crawl failed: %w", err)
+	}
+
+	name := opts.WordlistName
+	if name == "" {
+		name = crawlWordlistName(job)
+	}
+	info := types.WordlistInfo{
+		Description: "Words crawled from " + job.Target,
+		Tags:        []string{"crawl"},
+		Source:      job.Target,
+	}
+	id, err := m.wordlistMgr.Add(name, strings.NewReader(strings.Join(result.Words, "\n")), info)
+	if err != nil {
+		return fmt.Errorf("failed to save crawled wordlist: %w", err)
+	}
+
+	m.mu.Lock()
+	job.WordlistID = id
+	job.Progress = 100
+	m.mu.Unlock()
+
+	logging.Info("Crawl completed: %s (%d pages, %d words, wordlist %s)", job.ID, result.Pages, len(result.Words), id)
+	return nil
+}
+
+// crawlWordlistName names a crawled wordlist after the target's host
+func crawlWordlistName(job *types.Job) string {
+	host := job.Target
+	if u, err := url.Parse(job.Target); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	return fmt.Sprintf("crawl-%s-%s", host, job.ID)
+}
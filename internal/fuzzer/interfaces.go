@@ -0,0 +1,81 @@
+package fuzzer
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"fuzzer/internal/crawl"
+	"fuzzer/types"
+)
+
+// JobType is a scan mode. The manager reads the wordlist, applies the job's
+// rules and processors, and sends each request under the rate limit and
+// scope; the job type decides what to send for each payload and what counts
+// as a finding. Job types are registered with RegisterJobType.
+type JobType interface {
+	// Validate rejects a job whose target or options the type can't use.
+	// It is called before the job is created.
+	Validate(job *types.Job) error
+	// BuildRequests returns the requests to send for a payload. They are
+	// sent in order until a response is judged a finding.
+	BuildRequests(ctx context.Context, job *types.Job, payload string) ([]*http.Request, error)
+	// Judge reports whether a response is a finding, and the URL to record
+	// for it
+	Judge(job *types.Job, resp *Response) (url string, found bool)
+}
+
+// Runner is implemented by job types that drive the whole job themselves
+// instead of sending requests per payload, such as crawls. BuildRequests
+// and Judge aren't called for them, and they need no wordlist unless they
+// read one. The job's status is set from the returned error.
+type Runner interface {
+	Run(ctx context.Context, m *Manager, job *types.Job) error
+}
+
+// Follower is implemented by job types that start a new job from a
+// finding, such as subdomain scans recursing into the hosts they find. The
+// finding's URL has already been checked against the job's scope.
+type Follower interface {
+	Follow(job *types.Job, url string) *types.Job
+}
+
+// ClientProvider is implemented by job types that need an HTTP client other
+// than the default, which follows redirects
+type ClientProvider interface {
+	Client(job *types.Job) *http.Client
+}
+
+// Prober is implemented by job types that judge a payload from several
+// responses, such as comparing them with a baseline. Probe replaces the
+// manager's BuildRequests and Judge calls and returns the payload's
+// findings; their payload is filled in by the manager.
+type Prober interface {
+	Probe(ctx context.Context, job *types.Job, payload string, send SendFunc) []Hit
+}
+
+// Hit is a finding made by a Prober and the response it was made from,
+// which the job's match expression is evaluated against
+type Hit struct {
+	Finding  types.Finding
+	Response *Response
+}
+
+// SendFunc sends a request for a Prober under the rate limit and the job's
+// scope, reading the response with the job type's client
+type SendFunc func(req *http.Request) (*Response, error)
+
+// Seeder is implemented by job types that can find payloads of their own
+// before the wordlist is read, such as directory scans reading robots.txt.
+// Seeds is called when the job's Seed option is set, and its payloads are
+// sent first without the job's processors.
+type Seeder interface {
+	Seeds(ctx context.Context, m *Manager, job *types.Job) []crawl.Seed
+}
+
+// Linker is implemented by job types whose payloads are paths under the
+// target, letting the spider option turn the links in their findings into
+// payloads. Payload reports false for links outside the target.
+type Linker interface {
+	Payload(job *types.Job, link *url.URL) (string, bool)
+}
@@ -0,0 +1,168 @@
+package fuzzer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"fuzzer/internal/crawl"
+	"fuzzer/internal/logging"
+	"fuzzer/types"
+)
+
+func init() {
+	RegisterJobType(types.DirectoryType, directoryType{})
+	RegisterJobType(types.SubdomainType, subdomainType{})
+	RegisterJobType(types.CrawlType, crawlType{})
+	RegisterJobType(types.VerbType, verbType{})
+	RegisterJobType(types.ParamType, paramType{})
+	RegisterJobType(types.BackupType, backupType{})
+}
+
+// parseTarget parses a job's target as an http or https URL
+func parseTarget(job *types.Job) (*url.URL, error) {
+	target, err := url.Parse(job.Target)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("%w: target %q must be an http or https URL", types.ErrInvalidJob, job.Target)
+	}
+	return target, nil
+}
+
+// directoryType requests each payload as a path under the target
+type directoryType struct{}
+
+func (directoryType) Validate(job *types.Job) error {
+	_, err := parseTarget(job)
+	return err
+}
+
+func (directoryType) BuildRequests(ctx context.Context, job *types.Job, payload string) ([]*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/%s", job.Target, payload), nil)
+	if err != nil {
+		return nil, err
+	}
+	applyHeaders(req, job)
+	return []*http.Request{req}, nil
+}
+
+func (directoryType) Judge(job *types.Job, resp *Response) (string, bool) {
+	return resp.Request.URL.String(), matchesStatus(job, resp.StatusCode)
+}
+
+// Seeds returns the paths the target's robots.txt, sitemaps and
+// security.txt name
+func (directoryType) Seeds(ctx context.Context, m *Manager, job *types.Job) []crawl.Seed {
+	seeds, err := crawl.Seeds(ctx, &crawlFetcher{m: m, job: job}, job.Target, func(rawURL string) bool {
+		return m.allowed(job, rawURL)
+	})
+	if err != nil {
+		logging.Error("Seeding job %s failed: %v", job.ID, err)
+	}
+	return seeds
+}
+
+// Payload returns the path of a link on the target's host under its path,
+// without the query
+func (directoryType) Payload(job *types.Job, link *url.URL) (string, bool) {
+	target, err := url.Parse(job.Target)
+	if err != nil || link.Host != target.Host {
+		return "", false
+	}
+	base := strings.TrimSuffix(target.EscapedPath(), "/") + "/"
+	payload, found := strings.CutPrefix(link.EscapedPath(), base)
+	return payload, found && payload != ""
+}
+
+// subdomainType sends each payload as a virtual host to the target's
+// address, over http and then https
+type subdomainType struct{}
+
+func (subdomainType) Validate(job *types.Job) error {
+	_, err := parseTarget(job)
+	return err
+}
+
+func (subdomainType) BuildRequests(ctx context.Context, job *types.Job, payload string) ([]*http.Request, error) {
+	target, err := url.Parse(job.Target)
+	if err != nil {
+		return nil, err
+	}
+	subdomain := fmt.Sprintf("%s.%s", payload, target.Host)
+
+	var reqs []*http.Request
+	for _, scheme := range []string{"http", "https"} {
+		req, err := http.NewRequestWithContext(ctx, "GET", scheme+"://"+target.Host, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		// Add common headers that might help with virtual host detection
+		req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+		req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
+		req.Header.Set("Accept-Language", "en-US,en;q=0.5")
+		applyHeaders(req, job)
+
+		// Set the Host header to the subdomain we're testing. This comes after
+		// the job headers since it is the value being fuzzed.
+		req.Host = subdomain
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
+
+func (subdomainType) Judge(job *types.Job, resp *Response) (string, bool) {
+	return resp.Request.URL.Scheme + "://" + resp.Request.Host, isLikelyValidVHost(resp)
+}
+
+func (subdomainType) Client(job *types.Job) *http.Client {
+	return &http.Client{
+		Timeout: requestTimeout(job),
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+		},
+		// Don't follow redirects as they might reveal information
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Follow scans the discovered host for subdomains of its own
+func (subdomainType) Follow(job *types.Job, url string) *types.Job {
+	return &types.Job{
+		Target:     url,
+		WordlistID: job.WordlistID,
+		Type:       types.SubdomainType,
+		Options:    job.Options,
+	}
+}
+
+// isLikelyValidVHost checks if the response indicates a valid virtual host
+func isLikelyValidVHost(resp *Response) bool {
+	// Consider it valid if we get a successful response
+	if resp.StatusCode >= 200 && resp.StatusCode < 400 {
+		return true
+	}
+
+	// Check for specific status codes that might indicate a valid host
+	if resp.StatusCode == http.StatusUnauthorized ||
+		resp.StatusCode == http.StatusForbidden {
+		return true
+	}
+
+	// If we get a 404 or 500, check if it's a custom error page
+	// This might indicate a valid host with an error
+	if (resp.StatusCode == http.StatusNotFound ||
+		resp.StatusCode == http.StatusInternalServerError) &&
+		len(resp.Body) > 512 {
+		return true
+	}
+
+	return false
+}
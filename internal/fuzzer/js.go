@@ -0,0 +1,50 @@
+package fuzzer
+
+import (
+	"net/url"
+
+	"fuzzer/internal/crawl"
+	"fuzzer/internal/jsscan"
+	"fuzzer/internal/logging"
+	"fuzzer/types"
+)
+
+// JSFinding is the type of the findings extracted from scripts
+const JSFinding = "js"
+
+// scanScript records the endpoints and secrets in a script a job found, and
+// feeds the endpoints to the job's spider if it asks for that. Each script
+// is scanned once per run.
+func (r *jobRun) scanScript(resp *Response, depth int) {
+	if r.job.Options.JS == nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return
+	}
+	script := resp.Request.URL
+	if !crawl.IsScript(resp.Header.Get("Content-Type"), script.Path) || r.scanned[script.String()] {
+		return
+	}
+	r.scanned[script.String()] = true
+
+	var endpoints []*url.URL
+	for _, item := range jsscan.Scan(resp.Body) {
+		f := types.Finding{Type: JSFinding, URL: script.String(), File: script.String(), Line: item.Line}
+		switch item.Kind {
+		case jsscan.Endpoint:
+			if u, err := script.Parse(item.Value); err == nil {
+				u.Fragment = ""
+				f.URL = u.String()
+				endpoints = append(endpoints, u)
+			}
+			f.Detail = "endpoint " + item.Value
+		case jsscan.Secret:
+			f.Detail = item.Rule + ": " + item.Value
+		}
+		// Values stay out of the log, as they may be secrets
+		logging.Info("Found %s in %s:%d", item.Kind, script, item.Line)
+		r.m.addFinding(r.job, f)
+	}
+
+	if r.spider != nil && r.job.Options.JS.FeedPaths {
+		r.spider.feed(r.job, endpoints, script.String(), depth)
+	}
+}
@@ -2,15 +2,20 @@ package fuzzer
 
 import (
 	"context"
-	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
+	"strings"
 	"sync"
 	"time"
 
+	"fuzzer/internal/audit"
 	"fuzzer/internal/logging"
+	"fuzzer/internal/match"
+	"fuzzer/internal/payload"
+	"fuzzer/internal/rules"
+	"fuzzer/internal/scope"
 	"fuzzer/internal/storage"
 	"fuzzer/internal/wordlist"
 	"fuzzer/types"
@@ -31,7 +36,14 @@ type Manager struct {
 	wordlistMgr wordlist.WordlistStorer
 	rateLimit   float64
 	limiter     *rate.Limiter
+	defaults    types.JobOptions
+	scope       *scope.Scope
+	projects    map[string]*scope.Scope
+	auditor     audit.Recorder
 	jobs        map[string]*types.Job
+	running     map[string]context.CancelFunc
+	wg          sync.WaitGroup
+	closing     bool
 	mu          sync.RWMutex
 }
 
@@ -46,11 +58,185 @@ func NewManager(ctx context.Context, store storage.JobStorer, wordlistMgr wordli
 		rateLimit:   rateLimit,
 		limiter:     rate.NewLimiter(rate.Limit(rateLimit), 1),
 		jobs:        make(map[string]*types.Job),
+		running:     make(map[string]context.CancelFunc),
 	}
 }
 
-func (m *Manager) StartJob(target, wordlistID string, jobType types.JobType) error {
+// SetJobDefaults sets the options applied to jobs that leave them unset
+func (m *Manager) SetJobDefaults(defaults types.JobOptions) {
 	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	logging.Info("Setting job defaults: Timeout=%ds Headers=%d MatchStatus=%v",
+		defaults.Timeout, len(defaults.Headers), defaults.MatchStatus)
+	m.defaults = defaults
+}
+
+// SetScope sets the server-wide scope and the per-project scopes. Every job
+// must satisfy the server scope and, if it names a project, that project's.
+func (m *Manager) SetScope(server *scope.Scope, projects map[string]*scope.Scope) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	logging.Info("Setting target scope: Projects=%d", len(projects))
+	m.scope = server
+	m.projects = projects
+}
+
+// checkScope returns an error wrapping scope.ErrOutOfScope if the job may not
+// request rawURL
+func (m *Manager) checkScope(job *types.Job, rawURL string) error {
+	m.mu.RLock()
+	server := m.scope
+	project, hasProject := m.projects[job.Options.Project]
+	m.mu.RUnlock()
+
+	if err := server.Check(rawURL); err != nil {
+		return err
+	}
+	if job.Options.Project != "" {
+		if !hasProject {
+			return &scope.Violation{URL: rawURL, Reason: fmt.Sprintf("unknown project %q", job.Options.Project)}
+		}
+		if err := project.Check(rawURL); err != nil {
+			return fmt.Errorf("project %s: %w", job.Options.Project, err)
+		}
+	}
+	return nil
+}
+
+// SetAuditor records scope violations found while jobs run
+func (m *Manager) SetAuditor(auditor audit.Recorder) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.auditor = auditor
+}
+
+// allowed checks rawURL against the job's scope before a request is sent,
+// recording violations as audit events
+func (m *Manager) allowed(job *types.Job, rawURL string) bool {
+	err := m.checkScope(job, rawURL)
+	if err == nil {
+		return true
+	}
+
+	m.mu.RLock()
+	auditor := m.auditor
+	m.mu.RUnlock()
+
+	if auditor == nil {
+		logging.Audit("action=scope.violation job=%s project=%q url=%q reason=%q", job.ID, job.Options.Project, rawURL, err)
+		return false
+	}
+	auditor.Record("system", "scope.violation", map[string]string{
+		"jobId":   job.ID,
+		"project": job.Options.Project,
+		"url":     rawURL,
+		"reason":  err.Error(),
+	})
+	return false
+}
+
+// withDefaults fills unset fields of opts from the manager's job defaults.
+// Headers are merged, with the job's own headers taking precedence.
+func (m *Manager) withDefaults(opts types.JobOptions) types.JobOptions {
+	if opts.Timeout <= 0 {
+		opts.Timeout = m.defaults.Timeout
+	}
+	if len(opts.MatchStatus) == 0 {
+		opts.MatchStatus = m.defaults.MatchStatus
+	}
+	headers := make(map[string]string, len(m.defaults.Headers)+len(opts.Headers))
+	for k, v := range m.defaults.Headers {
+		headers[k] = v
+	}
+	for k, v := range opts.Headers {
+		headers[k] = v
+	}
+	opts.Headers = headers
+	return opts
+}
+
+func (m *Manager) StartJob(target, wordlistID string, jobType types.JobType, opts types.JobOptions) error {
+	m.mu.RLock()
+	_, projectExists := m.projects[opts.Project]
+	m.mu.RUnlock()
+	if opts.Project != "" && !projectExists {
+		logging.Error("Rejected job for %s: unknown project %q", target, opts.Project)
+		return fmt.Errorf("%w: unknown project %q", types.ErrInvalidJob, opts.Project)
+	}
+	jt, err := lookupJobType(jobType)
+	if err != nil {
+		logging.Error("Rejected job for %s: %v", target, err)
+		return err
+	}
+	if err := jt.Validate(&types.Job{Target: target, Type: jobType, WordlistID: wordlistID, Options: opts}); err != nil {
+		logging.Error("Rejected job for %s: %v", target, err)
+		if !errors.Is(err, types.ErrInvalidJob) {
+			err = fmt.Errorf("%w: %v", types.ErrInvalidJob, err)
+		}
+		return err
+	}
+	if wordlist.IsGenerator(wordlistID) {
+		if _, err := wordlist.ParseGenerator(wordlistID); err != nil {
+			logging.Error("Rejected job for %s: %v", target, err)
+			return fmt.Errorf("%w: %v", types.ErrInvalidJob, err)
+		}
+	}
+	if opts.RankByHits && m.wordlistMgr.Generated(wordlistID) {
+		logging.Error("Rejected job for %s: %s is generated and can't be ranked", target, wordlistID)
+		return fmt.Errorf("%w: generated wordlists can't be ranked by hits", types.ErrInvalidJob)
+	}
+	set, err := rules.Parse(opts.Rules)
+	if err != nil {
+		logging.Error("Rejected job for %s: %v", target, err)
+		return fmt.Errorf("%w: %v", types.ErrInvalidJob, err)
+	}
+	if _, err := payload.Parse(opts.Processors); err != nil {
+		logging.Error("Rejected job for %s: %v", target, err)
+		return fmt.Errorf("%w: %v", types.ErrInvalidJob, err)
+	}
+	if _, err := compileMatch(opts.Match); err != nil {
+		logging.Error("Rejected job for %s: %v", target, err)
+		return fmt.Errorf("%w: %v", types.ErrInvalidJob, err)
+	}
+	for _, method := range opts.Methods {
+		if !validMethod(method) {
+			logging.Error("Rejected job for %s: invalid method %q", target, method)
+			return fmt.Errorf("%w: invalid method %q", types.ErrInvalidJob, method)
+		}
+	}
+	if err := m.checkScope(&types.Job{Options: opts}, target); err != nil {
+		logging.Error("Rejected job for %s: %v", target, err)
+		return err
+	}
+	// Verify the wordlist exists before the job is saved. Runners such as
+	// crawls needn't have one.
+	if _, runs := jt.(Runner); !runs || wordlistID != "" {
+		wl := m.wordlistMgr.Get(wordlistID)
+		if wl == nil {
+			logging.Error("Wordlist not found: %s", wordlistID)
+			return fmt.Errorf("wordlist not found: %s", wordlistID)
+		}
+		if len(opts.Rules) > 0 {
+			if _, err := wordlist.Keyspace(wl.Lines, set.Len()); err != nil {
+				logging.Error("Rejected job for %s: %v", target, err)
+				return fmt.Errorf("%w: %v", types.ErrInvalidJob, err)
+			}
+		}
+	}
+
+	m.mu.Lock()
+	if m.closing {
+		m.mu.Unlock()
+		logging.Error("Rejected job for %s: manager is shutting down", target)
+		return types.ErrShuttingDown
+	}
+	// Count the job while still holding the lock so Shutdown can't finish
+	// waiting between here and the goroutine starting
+	m.wg.Add(1)
+
 	job := &types.Job{
 		ID:         fmt.Sprintf("job-%d", len(m.jobs)+1),
 		Target:     target,
@@ -59,26 +245,21 @@ func (m *Manager) StartJob(target, wordlistID string, jobType types.JobType) err
 		Type:       jobType,
 		StartTime:  time.Now(),
 		Findings:   make([]types.Finding, 0),
+		Options:    m.withDefaults(opts),
 	}
 
 	logging.Info("Starting new job: ID=%s Target=%s Type=%s", job.ID, target, jobType)
 
 	// Save to both memory and persistent storage
-	m.jobs[job.ID] = job
 	if err := m.store.SaveJob(job); err != nil {
 		m.mu.Unlock()
+		m.wg.Done()
 		logging.Error("Failed to save job: %v", err)
 		return fmt.Errorf("failed to save job: %w", err)
 	}
+	m.jobs[job.ID] = job
 	m.mu.Unlock()
 
-	// Verify wordlist exists before starting goroutine
-	wordlist := m.wordlistMgr.Get(wordlistID)
-	if wordlist == nil {
-		logging.Error("Wordlist not found: %s", wordlistID)
-		return fmt.Errorf("wordlist not found: %s", wordlistID)
-	}
-
 	// Start actual fuzzing in a goroutine
 	go m.runJob(job)
 
@@ -109,6 +290,38 @@ func (m *Manager) GetJobs() ([]*types.Job, error) {
 	return jobs, nil
 }
 
+// WordlistHits counts the findings of every job by the wordlist it used.
+// Only findings for the wordlist's own words count, not seeded or crawled
+// ones, nor the bypasses and script findings that follow up on a hit.
+func (m *Manager) WordlistHits() map[string]int {
+	storedJobs, err := m.store.ListJobs()
+	if err != nil {
+		logging.Error("Failed to list stored jobs for wordlist hits: %v", err)
+	}
+
+	// Findings are appended under the lock while jobs run
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	hits := make(map[string]int)
+	count := func(job *types.Job) {
+		for _, f := range job.Findings {
+			if f.Source == types.SourceBruteforce && f.Type == string(job.Type) {
+				hits[job.WordlistID]++
+			}
+		}
+	}
+	for _, job := range m.jobs {
+		count(job)
+	}
+	for _, job := range storedJobs {
+		if _, exists := m.jobs[job.ID]; !exists {
+			count(job)
+		}
+	}
+	return hits
+}
+
 func (m *Manager) StopJob(jobID string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -120,6 +333,9 @@ func (m *Manager) StopJob(jobID string) error {
 	}
 
 	logging.Info("Stopping job: %s", jobID)
+	if cancel, ok := m.running[jobID]; ok {
+		cancel()
+	}
 	job.Status = "stopped"
 	if err := m.store.SaveJob(job); err != nil {
 		logging.Error("Failed to save stopped job status: %v", err)
@@ -140,6 +356,9 @@ func (m *Manager) DeleteJob(jobID string) error {
 	}
 
 	logging.Info("Deleting job: %s", jobID)
+	if cancel, ok := m.running[jobID]; ok {
+		cancel()
+	}
 	delete(m.jobs, jobID)
 	if err := m.store.DeleteJob(jobID); err != nil {
 		logging.Error("Failed to delete job: %v", err)
@@ -149,54 +368,129 @@ func (m *Manager) DeleteJob(jobID string) error {
 	return nil
 }
 
+// Shutdown stops the manager from accepting new jobs, cancels the running
+// ones and waits for them to record their final status before flushing the
+// store. Jobs still running when ctx expires are marked interrupted and the
+// store is flushed anyway; ctx's error is returned in that case.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.closing = true
+	m.mu.Unlock()
+
+	logging.Info("Shutting down fuzzer manager, cancelling running jobs")
+	m.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+		logging.Info("All running jobs have finished")
+	case <-ctx.Done():
+		logging.Error("Timed out waiting for jobs to finish: %v", ctx.Err())
+		err = ctx.Err()
+	}
+
+	m.mu.Lock()
+	for _, job := range m.jobs {
+		if job.Status == "running" {
+			job.Status = "interrupted"
+		}
+	}
+	m.mu.Unlock()
+
+	if saveErr := m.store.Save(); saveErr != nil {
+		logging.Error("Failed to flush job store: %v", saveErr)
+		if err == nil {
+			err = fmt.Errorf("failed to flush job store: %w", saveErr)
+		}
+	}
+	return err
+}
+
 func (m *Manager) runJob(job *types.Job) {
+	defer m.wg.Done()
+
 	jobCtx, cancel := context.WithCancel(m.ctx)
 	defer cancel()
 
+	if job.ID != "" {
+		m.mu.Lock()
+		m.running[job.ID] = cancel
+		m.mu.Unlock()
+		defer func() {
+			m.mu.Lock()
+			delete(m.running, job.ID)
+			m.mu.Unlock()
+		}()
+	}
+
 	logging.Info("Running job: ID=%s Target=%s Type=%s", job.ID, job.Target, job.Type)
 
-	wordlist := m.wordlistMgr.Get(job.WordlistID)
-	if wordlist == nil {
-		logging.Error("Failed to get wordlist for job: %s", job.ID)
+	jt, err := lookupJobType(job.Type)
+	if err != nil {
+		logging.Error("Failed to run job %s: %v", job.ID, err)
 		m.updateJobStatus(job, "failed")
 		return
 	}
-	totalWords := len(wordlist.Words)
+	if runner, ok := jt.(Runner); ok {
+		err := runner.Run(jobCtx, m, job)
+		switch {
+		case jobCtx.Err() != nil:
+			m.cancelledJob(job)
+		case err != nil:
+			logging.Error("Job %s failed: %v", job.ID, err)
+			m.updateJobStatus(job, "failed")
+		default:
+			logging.Info("Job completed: %s", job.ID)
+			m.updateJobStatus(job, "completed")
+		}
+		return
+	}
 
-	for i, word := range wordlist.Words {
+	words, totalWords, err := m.openWords(job)
+	if err != nil {
+		logging.Error("Failed to open wordlist for job %s: %v", job.ID, err)
+		m.updateJobStatus(job, "failed")
+		return
+	}
+	defer words.Close()
+	// Already validated when the job was started
+	processors, _ := payload.Parse(job.Options.Processors)
+	expr, _ := compileMatch(job.Options.Match)
+
+	r := m.newJobRun(jobCtx, job, jt, expr)
+
+	if seeder, ok := jt.(Seeder); ok && job.Options.Seed {
+		seeds := seeder.Seeds(jobCtx, m, job)
+		logging.Info("Seeding job %s with %d paths", job.ID, len(seeds))
+		for _, seed := range seeds {
+			if jobCtx.Err() != nil {
+				break
+			}
+			r.try(seed.Path, types.Finding{Source: types.SourceSeed, Detail: "from " + seed.From}, 0)
+			r.drain()
+		}
+	}
+
+	for i := 0; words.Next(); i++ {
+		word := words.Word()
 		select {
 		case <-jobCtx.Done():
-			logging.Info("Job stopped: %s", job.ID)
-			m.updateJobStatus(job, "stopped")
+			m.cancelledJob(job)
 			return
 		default:
-			err := m.limiter.Wait(jobCtx)
-			if err != nil {
-				logging.Debug("Rate limiter error: %v", err)
-				continue
-			}
+			// Words appended while the job runs are read too, so the
+			// count can be passed
+			job.Progress = min(100, int(float64(i+1)/float64(totalWords)*100))
 
-			job.Progress = int(float64(i+1) / float64(totalWords) * 100)
-
-			switch job.Type {
-			case types.DirectoryType:
-				if url := m.checkDirectory(job.Target, word); url != "" {
-					logging.Info("Directory found: %s", url)
-					m.addFinding(job, url, string(types.DirectoryType))
-				}
-
-			case types.SubdomainType:
-				if url := m.checkSubdomain(job.Target, word); url != "" {
-					logging.Info("Subdomain found: %s", url)
-					m.addFinding(job, url, string(types.SubdomainType))
-					// Pass context to recursive call
-					go m.runJob(&types.Job{
-						Target:     url,
-						WordlistID: job.WordlistID,
-						Type:       types.SubdomainType,
-					})
-				}
-			}
+			encoded := processors.Apply(word)
+			r.try(encoded, types.Finding{Payload: word, Encoded: encoded, Source: types.SourceBruteforce}, 0)
+			r.drain()
 
 			if i%100 == 0 {
 				logging.Debug("Saving job progress: %d%%", job.Progress)
@@ -204,11 +498,164 @@ func (m *Manager) runJob(job *types.Job) {
 			}
 		}
 	}
+	if err := words.Err(); err != nil {
+		logging.Error("Failed to read wordlist for job %s: %v", job.ID, err)
+		m.updateJobStatus(job, "failed")
+		return
+	}
+
+	// The context may have been cancelled while waiting on the last word
+	if jobCtx.Err() != nil {
+		m.cancelledJob(job)
+		return
+	}
 
 	logging.Info("Job completed: %s", job.ID)
 	m.updateJobStatus(job, "completed")
 }
 
+// jobRun sends the payloads of a running job and records its findings
+type jobRun struct {
+	m      *Manager
+	ctx    context.Context
+	job    *types.Job
+	jt     JobType
+	expr   *match.Expr
+	send   SendFunc
+	prober Prober
+	// methods each payload is sent with; an empty method leaves the
+	// type's own in place
+	methods []string
+	// spider is nil unless the job follows links in its findings
+	spider *spider
+	// scanned holds the scripts already scanned for endpoints and secrets
+	scanned map[string]bool
+}
+
+func (m *Manager) newJobRun(ctx context.Context, job *types.Job, jt JobType, expr *match.Expr) *jobRun {
+	r := &jobRun{m: m, ctx: ctx, job: job, jt: jt, expr: expr, send: m.sender(ctx, job, jt), scanned: make(map[string]bool)}
+	r.prober, _ = jt.(Prober)
+	r.spider = newSpider(job, jt)
+	r.methods = job.Options.Methods
+	if len(r.methods) == 0 {
+		r.methods = []string{""}
+	}
+	return r
+}
+
+// try sends a payload and records its findings, filling in their payload,
+// source and detail from from. depth is how many links away from the
+// wordlist or seeds the payload was found.
+func (r *jobRun) try(payload string, from types.Finding, depth int) {
+	m, job := r.m, r.job
+	if r.prober != nil {
+		// Probers choose their own methods and wait on the rate limit for
+		// each request they send
+		for _, hit := range r.prober.Probe(r.ctx, job, payload, r.send) {
+			if !matches(r.expr, hit.Response, true) {
+				continue
+			}
+			f := hit.Finding
+			f.Payload, f.Encoded, f.Source = from.Payload, from.Encoded, from.Source
+			logging.Info("Found %s: %s", job.Type, f.URL)
+			m.addFinding(job, f)
+		}
+		return
+	}
+
+	var followed string
+	for _, method := range r.methods {
+		if err := m.limiter.Wait(r.ctx); err != nil {
+			logging.Debug("Rate limiter error: %v", err)
+			continue
+		}
+		url, resp := m.probe(r.ctx, job, r.jt, r.expr, method, payload)
+		if url == "" {
+			continue
+		}
+		logging.Info("Found %s: %s", job.Type, url)
+		f := from
+		f.URL, f.Method = url, method
+		m.addFinding(job, f)
+		if job.Options.BypassForbidden && resp.StatusCode == http.StatusForbidden {
+			for _, f := range bypassForbidden(r.ctx, url, resp, r.send) {
+				logging.Info("Bypassed 403 on %s: %s", url, f.Detail)
+				f.Source = from.Source
+				m.addFinding(job, f)
+			}
+		}
+		if r.spider != nil {
+			r.spider.visit(job, payload, resp, depth)
+		}
+		r.scanScript(resp, depth)
+		if followed == "" {
+			followed = url
+		}
+	}
+
+	// A payload found with several methods is still followed once.
+	// Discovered hosts are only followed if they are in scope.
+	if follower, ok := r.jt.(Follower); ok && followed != "" && m.allowed(job, followed) {
+		m.wg.Add(1)
+		go m.runJob(follower.Follow(job, followed))
+	}
+}
+
+// drain sends the payloads the spider has queued, including those queued
+// while draining
+func (r *jobRun) drain() {
+	if r.spider == nil {
+		return
+	}
+	for r.ctx.Err() == nil {
+		link, ok := r.spider.next()
+		if !ok {
+			return
+		}
+		r.try(link.payload, types.Finding{Source: types.SourceCrawled, Detail: "linked from " + link.from}, link.depth)
+	}
+}
+
+// openWords opens a job's wordlist with its rules applied, ranked if the
+// job asks for it, and returns the number of words expected
+func (m *Manager) openWords(job *types.Job) (wordlist.Iterator, int, error) {
+	wl := m.wordlistMgr.Get(job.WordlistID)
+	if wl == nil {
+		return nil, 0, fmt.Errorf("%w: %s", wordlist.ErrNotFound, job.WordlistID)
+	}
+	var words wordlist.Iterator
+	var err error
+	if job.Options.RankByHits {
+		words, err = m.wordlistMgr.IterateRanked(job.WordlistID, job.Type)
+	} else {
+		words, err = m.wordlistMgr.Iterate(job.WordlistID)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	total := wl.Lines
+	if len(job.Options.Rules) > 0 {
+		// Already validated when the job was started
+		set, _ := rules.Parse(job.Options.Rules)
+		words = wordlist.WithRules(words, set)
+		total, _ = wordlist.Keyspace(total, set.Len())
+	}
+	return words, total, nil
+}
+
+// cancelledJob records the final status of a job whose context was cancelled.
+// A cancelled manager context means the server is shutting down rather than
+// the user stopping the job.
+func (m *Manager) cancelledJob(job *types.Job) {
+	if m.ctx.Err() != nil {
+		logging.Info("Job interrupted by shutdown: %s (progress %d%%)", job.ID, job.Progress)
+		m.updateJobStatus(job, "interrupted")
+		return
+	}
+	logging.Info("Job stopped: %s", job.ID)
+	m.updateJobStatus(job, "stopped")
+}
+
 func (m *Manager) updateJobStatus(job *types.Job, status string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -218,122 +665,227 @@ func (m *Manager) updateJobStatus(job *types.Job, status string) {
 	m.store.SaveJob(job)
 }
 
+// UpdateRateLimit changes the requests per second allowed across all jobs.
+// The limiter is changed in place, so running jobs slow down or speed up
+// straight away.
 func (m *Manager) UpdateRateLimit(newLimit float64) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	logging.Info("Updating rate limit from %f to %f", m.rateLimit, newLimit)
 	m.rateLimit = newLimit
-	m.limiter = rate.NewLimiter(rate.Limit(newLimit), 1)
+	m.limiter.SetLimit(rate.Limit(newLimit))
 }
 
-func (m *Manager) checkDirectory(target, word string) string {
-	url := fmt.Sprintf("%s/%s", target, word)
-	resp, err := http.Get(url)
-	if err != nil {
-		return ""
+// requestTimeout returns the job's per-request timeout, falling back to 10s
+func requestTimeout(job *types.Job) time.Duration {
+	if job.Options.Timeout > 0 {
+		return time.Duration(job.Options.Timeout) * time.Second
 	}
-	defer resp.Body.Close()
+	return 10 * time.Second
+}
 
-	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusForbidden {
-		return url
+// applyHeaders sets the job's configured headers on the request
+func applyHeaders(req *http.Request, job *types.Job) {
+	for k, v := range job.Options.Headers {
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
 	}
-	return ""
 }
 
-func (m *Manager) checkSubdomain(target, word string) string {
-	// Parse the original target URL to get the base host and protocol
-	parsedTarget, err := url.Parse(target)
-	if err != nil {
-		return ""
-	}
-
-	// Form the subdomain
-	subdomain := fmt.Sprintf("%s.%s", word, parsedTarget.Host)
-
-	// Create HTTP client with custom settings
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-		Transport: &http.Transport{
-			DisableKeepAlives: true,
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
-		},
-		// Don't follow redirects as they might reveal information
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
-	}
-
-	// Try both HTTP and HTTPS on the original host
-	protocols := []string{"http://", "https://"}
-	for _, protocol := range protocols {
-		// Create the request to the original host
-		baseURL := fmt.Sprintf("%s%s", protocol, parsedTarget.Host)
-		req, err := http.NewRequest("GET", baseURL, nil)
-		if err != nil {
-			continue
+// validMethod reports whether method is a valid HTTP method token. Made-up
+// methods are allowed, as some servers route them anyway.
+func validMethod(method string) bool {
+	if method == "" {
+		return false
+	}
+	for _, c := range method {
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || strings.ContainsRune("!#$%&'*+-.^_`|~", c)) {
+			return false
 		}
+	}
+	return true
+}
 
-		// Set the Host header to the subdomain we're testing
-		req.Host = subdomain
+// matchesStatus reports whether code is one of the job's match codes. Jobs
+// without match codes fall back to 200 and 403.
+func matchesStatus(job *types.Job, code int) bool {
+	if len(job.Options.MatchStatus) == 0 {
+		return code == http.StatusOK || code == http.StatusForbidden
+	}
+	for _, c := range job.Options.MatchStatus {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
 
-		// Add common headers that might help with virtual host detection
-		req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-		req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
-		req.Header.Set("Accept-Language", "en-US,en;q=0.5")
+// maxResponseBody caps how much of each response is read for judging
+const maxResponseBody = 1 << 20
+
+// Response is a response as read for judging, with its body already read
+type Response struct {
+	Request    *http.Request
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// Duration is the time from sending the request to reading the body
+	Duration time.Duration
+}
 
-		resp, err := client.Do(req)
+// compileMatch compiles a job's match expression, returning nil if it has
+// none
+func compileMatch(src string) (*match.Expr, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, nil
+	}
+	return match.Compile(src)
+}
+
+// judge decides whether a response is a finding. A match expression
+// overrides the job type's verdict, which it can still refer to as found.
+func judge(job *types.Job, jt JobType, expr *match.Expr, resp *Response) (string, bool) {
+	url, found := jt.Judge(job, resp)
+	return url, matches(expr, resp, found)
+}
+
+// matches evaluates a match expression against a response given the job
+// type's verdict, which stands if there is no expression. Findings made by
+// comparing responses, as Probers and parameter jobs make them, are passed
+// as found so the expression filters them.
+func matches(expr *match.Expr, resp *Response, found bool) bool {
+	if expr == nil {
+		return found
+	}
+	return expr.Match(&match.Response{
+		Method:     resp.Request.Method,
+		URL:        resp.Request.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       resp.Body,
+		Duration:   resp.Duration,
+		Found:      found,
+	})
+}
+
+// probe sends the job type's requests for a payload until one is judged a
+// finding, returning its URL and the response. A method other than ""
+// replaces the requests' own. Requests outside the job's scope are skipped.
+func (m *Manager) probe(ctx context.Context, job *types.Job, jt JobType, expr *match.Expr, method, payload string) (string, *Response) {
+	reqs, err := jt.BuildRequests(ctx, job, payload)
+	if err != nil {
+		logging.Debug("Failed to build requests for %q: %v", payload, err)
+		return "", nil
+	}
+
+	client := m.clientFor(job, jt)
+	for _, req := range reqs {
+		if method != "" {
+			req.Method = method
+		}
+		if !m.allowed(job, req.URL.String()) {
+			continue
+		}
+		resp, err := send(client, req)
 		if err != nil {
+			logging.Debug("Request failed: %s: %v", req.URL, err)
 			continue
 		}
-
-		// Read a small portion of the body to help with virtual host detection
-		bodyBytes, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		resp.Body.Close()
-
-		// Check if this might be a valid virtual host
-		// You might want to adjust these detection methods based on your needs
-		if isLikelyValidVHost(resp, bodyBytes) {
-			return fmt.Sprintf("%s%s", protocol, subdomain)
+		if url, found := judge(job, jt, expr, resp); found {
+			return url, resp
 		}
 	}
-
-	return ""
+	return "", nil
 }
 
-// isLikelyValidVHost checks if the response indicates a valid virtual host
-func isLikelyValidVHost(resp *http.Response, bodyBytes []byte) bool {
-	// Consider it valid if we get a successful response
-	if resp.StatusCode >= 200 && resp.StatusCode < 400 {
-		return true
+// maxRedirects is how many redirects a job's client follows, as many as
+// the default client
+const maxRedirects = 10
+
+// clientFor returns the HTTP client for a job's requests. Every redirect
+// is checked against the job's scope before it is followed, so an in-scope
+// target can't send the job to a host it mustn't touch.
+func (m *Manager) clientFor(job *types.Job, jt JobType) *http.Client {
+	client := &http.Client{Timeout: requestTimeout(job)}
+	if provider, ok := jt.(ClientProvider); ok {
+		client = provider.Client(job)
+	}
+	next := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !m.allowed(job, req.URL.String()) {
+			return fmt.Errorf("%w: redirect to %s", scope.ErrOutOfScope, req.URL)
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return nil
 	}
+	return client
+}
 
-	// Check for specific status codes that might indicate a valid host
-	if resp.StatusCode == http.StatusUnauthorized ||
-		resp.StatusCode == http.StatusForbidden {
-		return true
+// sender returns the function a Prober sends its requests with. Each
+// request waits on the rate limit, and requests outside the job's scope are
+// refused.
+func (m *Manager) sender(ctx context.Context, job *types.Job, jt JobType) SendFunc {
+	client := m.clientFor(job, jt)
+	return func(req *http.Request) (*Response, error) {
+		if !m.allowed(job, req.URL.String()) {
+			return nil, fmt.Errorf("%w: %s", scope.ErrOutOfScope, req.URL)
+		}
+		if err := m.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		return send(client, req)
 	}
+}
 
-	// If we get a 404 or 500, check if it's a custom error page
-	// This might indicate a valid host with an error
-	if (resp.StatusCode == http.StatusNotFound ||
-		resp.StatusCode == http.StatusInternalServerError) &&
-		len(bodyBytes) > 512 {
-		return true
+// send sends req and reads up to maxResponseBody of the response
+func send(client *http.Client, req *http.Request) (*Response, error) {
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
 
-	return false
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if err != nil {
+		return nil, err
+	}
+	return &Response{
+		Request:    req,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+		Duration:   time.Since(start),
+	}, nil
 }
 
-func (m *Manager) addFinding(job *types.Job, url, findingType string) {
+// addFinding records a finding on the job and teaches the wordlist manager
+// that its payload was productive. The type defaults to the job's and the
+// time to now.
+func (m *Manager) addFinding(job *types.Job, f types.Finding) {
+	if f.Type == "" {
+		f.Type = string(job.Type)
+	}
+	if f.Found.IsZero() {
+		f.Found = time.Now()
+	}
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	job.Findings = append(job.Findings, f)
+	m.mu.Unlock()
 
-	job.Findings = append(job.Findings, types.Finding{
-		URL:   url,
-		Type:  findingType,
-		Found: time.Now(),
-	})
+	if f.Payload == "" {
+		return
+	}
+	if err := m.wordlistMgr.Learn(job.Type, f.Payload); err != nil {
+		logging.Error("Failed to learn from finding %s: %v", f.URL, err)
+	}
 }
@@ -0,0 +1,327 @@
+package fuzzer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"fuzzer/internal/logging"
+	"fuzzer/internal/payload"
+	"fuzzer/types"
+)
+
+const (
+	defaultParamBatch = 32
+	maxParamBatch     = 500
+	// paramValue is sent as the value of every parameter. It is unusual
+	// enough that finding it in a response means it was reflected.
+	paramValue = "fzq7x1"
+	// junkParam is sent alone for the baselines, so a page that changes
+	// with any parameter at all isn't mistaken for one that knows them
+	junkParam = "fzq7xjunk"
+)
+
+// paramLocations are where a parameter discovery job can send names
+var paramLocations = []string{"query", "form", "json"}
+
+// paramType takes a known endpoint and sends the wordlist's words to it as
+// parameter names, in batches. A batch whose response differs from the
+// baseline is split in half until the names responsible are found.
+type paramType struct{}
+
+func (paramType) Validate(job *types.Job) error {
+	if _, err := parseTarget(job); err != nil {
+		return err
+	}
+	if job.WordlistID == "" {
+		return fmt.Errorf("%w: parameter discovery needs a wordlist of names", types.ErrInvalidJob)
+	}
+	if opts := job.Options.Params; opts != nil {
+		for _, loc := range opts.Locations {
+			if !slices.Contains(paramLocations, loc) {
+				return fmt.Errorf("%w: unknown parameter location %q", types.ErrInvalidJob, loc)
+			}
+		}
+		if opts.BatchSize < 0 || opts.BatchSize > maxParamBatch {
+			return fmt.Errorf("%w: batch size must be between 1 and %d", types.ErrInvalidJob, maxParamBatch)
+		}
+	}
+	return nil
+}
+
+// BuildRequests returns the query request for a single name
+func (paramType) BuildRequests(ctx context.Context, job *types.Job, payload string) ([]*http.Request, error) {
+	req, err := paramRequest(ctx, job, "query", []string{payload})
+	if err != nil {
+		return nil, err
+	}
+	return []*http.Request{req}, nil
+}
+
+// Judge isn't called, as parameters are found by comparing responses
+func (paramType) Judge(job *types.Job, resp *Response) (string, bool) {
+	return job.Target, false
+}
+
+// Client doesn't follow redirects, so a name that changes where the
+// endpoint redirects is seen
+func (paramType) Client(job *types.Job) *http.Client {
+	return &http.Client{
+		Timeout: requestTimeout(job),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// paramName is a word from the wordlist and the name it was encoded to
+type paramName struct {
+	word string
+	name string
+}
+
+// Run reads the wordlist in batches and searches each batch in every
+// location with a stable baseline
+func (p paramType) Run(ctx context.Context, m *Manager, job *types.Job) error {
+	words, total, err := m.openWords(job)
+	if err != nil {
+		return err
+	}
+	defer words.Close()
+	// Already validated when the job was started
+	processors, _ := payload.Parse(job.Options.Processors)
+	expr, _ := compileMatch(job.Options.Match)
+
+	locations, size := paramLocations, defaultParamBatch
+	if opts := job.Options.Params; opts != nil {
+		if len(opts.Locations) > 0 {
+			locations = opts.Locations
+		}
+		if opts.BatchSize > 0 {
+			size = opts.BatchSize
+		}
+	}
+
+	d := &paramDiscovery{ctx: ctx, job: job, send: m.sender(ctx, job, p), baselines: make(map[string]fingerprint)}
+	for _, loc := range locations {
+		if err := d.baseline(loc); err != nil {
+			logging.Error("Skipping %s parameters for job %s: %v", loc, job.ID, err)
+		}
+	}
+	if len(d.baselines) == 0 {
+		return fmt.Errorf("no stable baseline for %s", job.Target)
+	}
+
+	var batch []paramName
+	flush := func() {
+		for _, loc := range locations {
+			if _, ok := d.baselines[loc]; !ok {
+				continue
+			}
+			for _, hit := range d.search(loc, batch) {
+				if !matches(expr, hit.resp, true) {
+					continue
+				}
+				logging.Info("Found %s parameter for %s: %s", loc, job.Target, hit.name)
+				m.addFinding(job, types.Finding{
+					URL:     job.Target,
+					Method:  paramMethod(loc),
+					Payload: hit.word,
+					Encoded: hit.name,
+					Source:  types.SourceBruteforce,
+					Detail:  fmt.Sprintf("%s parameter %s: %s", loc, hit.name, hit.detail),
+				})
+			}
+		}
+		batch = batch[:0]
+	}
+
+	for read := 1; words.Next(); read++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		word := words.Word()
+		batch = append(batch, paramName{word: word, name: processors.Apply(word)})
+		if len(batch) == size {
+			flush()
+			job.Progress = min(100, read*100/max(total, 1))
+		}
+	}
+	if err := words.Err(); err != nil {
+		return fmt.Errorf("failed to read wordlist: %w", err)
+	}
+	if len(batch) > 0 {
+		flush()
+	}
+
+	m.mu.Lock()
+	job.Progress = 100
+	m.mu.Unlock()
+	return nil
+}
+
+// paramMethod is the request method names are sent with in a location
+func paramMethod(loc string) string {
+	if loc == "query" {
+		return "GET"
+	}
+	return "POST"
+}
+
+// paramRequest builds a request sending each name with paramValue
+func paramRequest(ctx context.Context, job *types.Job, loc string, names []string) (*http.Request, error) {
+	target, err := url.Parse(job.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	var req *http.Request
+	switch loc {
+	case "query":
+		query := target.Query()
+		for _, name := range names {
+			query.Set(name, paramValue)
+		}
+		target.RawQuery = query.Encode()
+		req, err = http.NewRequestWithContext(ctx, "GET", target.String(), nil)
+	case "form":
+		form := url.Values{}
+		for _, name := range names {
+			form.Set(name, paramValue)
+		}
+		req, err = http.NewRequestWithContext(ctx, "POST", target.String(), strings.NewReader(form.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	case "json":
+		fields := make(map[string]string, len(names))
+		for _, name := range names {
+			fields[name] = paramValue
+		}
+		body, _ := json.Marshal(fields)
+		req, err = http.NewRequestWithContext(ctx, "POST", target.String(), bytes.NewReader(body))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	default:
+		return nil, fmt.Errorf("unknown parameter location %q", loc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	applyHeaders(req, job)
+	return req, nil
+}
+
+// fingerprint is what parameter discovery and verb jobs compare responses
+// by. Lengths aren't compared, as pages that echo their URL change length
+// with it.
+type fingerprint struct {
+	status      int
+	lines       int
+	words       int
+	location    string
+	reflections int
+}
+
+func fingerprintOf(resp *Response) fingerprint {
+	return fingerprint{
+		status:      resp.StatusCode,
+		lines:       bytes.Count(resp.Body, []byte{'\n'}),
+		words:       len(bytes.Fields(resp.Body)),
+		location:    resp.Header.Get("Location"),
+		reflections: bytes.Count(resp.Body, []byte(paramValue)),
+	}
+}
+
+// paramDiscovery holds the baselines of a parameter discovery job
+type paramDiscovery struct {
+	ctx       context.Context
+	job       *types.Job
+	send      SendFunc
+	baselines map[string]fingerprint
+}
+
+func (d *paramDiscovery) request(loc string, names []string) (*Response, error) {
+	req, err := paramRequest(d.ctx, d.job, loc, names)
+	if err != nil {
+		return nil, err
+	}
+	return d.send(req)
+}
+
+// baseline records the fingerprint of a location sent only the junk
+// parameter, which must come back the same twice
+func (d *paramDiscovery) baseline(loc string) error {
+	var prints []fingerprint
+	for range 2 {
+		resp, err := d.request(loc, []string{junkParam})
+		if err != nil {
+			return fmt.Errorf("baseline failed: %w", err)
+		}
+		prints = append(prints, fingerprintOf(resp))
+	}
+	if prints[0] != prints[1] {
+		return fmt.Errorf("responses differ without any parameters")
+	}
+	d.baselines[loc] = prints[0]
+	return nil
+}
+
+// paramHit is a name found to change the response, how it changed it and
+// the response it was sent alone in
+type paramHit struct {
+	paramName
+	detail string
+	resp   *Response
+}
+
+// search sends a batch and, if the response differs from the baseline,
+// splits it until the names responsible are found
+func (d *paramDiscovery) search(loc string, batch []paramName) []paramHit {
+	if len(batch) == 0 || d.ctx.Err() != nil {
+		return nil
+	}
+	names := make([]string, len(batch))
+	for i, p := range batch {
+		names[i] = p.name
+	}
+	resp, err := d.request(loc, names)
+	if err != nil {
+		logging.Debug("Parameter request failed: %v", err)
+		return nil
+	}
+	detail := d.compare(loc, fingerprintOf(resp))
+	if detail == "" {
+		return nil
+	}
+	if len(batch) == 1 {
+		return []paramHit{{paramName: batch[0], detail: detail, resp: resp}}
+	}
+	mid := len(batch) / 2
+	return append(d.search(loc, batch[:mid]), d.search(loc, batch[mid:])...)
+}
+
+// compare describes how a fingerprint differs from the location's
+// baseline, or returns "" if it doesn't
+func (d *paramDiscovery) compare(loc string, got fingerprint) string {
+	base := d.baselines[loc]
+	switch {
+	case got.status != base.status:
+		return fmt.Sprintf("status %d, baseline %d", got.status, base.status)
+	case got.location != base.location:
+		return fmt.Sprintf("redirects to %q, baseline %q", got.location, base.location)
+	// A page reflecting the junk parameter reflects every name, so
+	// reflections only count when it didn't
+	case base.reflections == 0 && got.reflections > 0:
+		return "value reflected"
+	case got.lines != base.lines || got.words != base.words:
+		return fmt.Sprintf("%d lines and %d words, baseline %d and %d", got.lines, got.words, base.lines, base.words)
+	}
+	return ""
+}
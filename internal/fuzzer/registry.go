@@ -0,0 +1,53 @@
+package fuzzer
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"fuzzer/types"
+)
+
+var (
+	jobTypesMu sync.RWMutex
+	jobTypes   = make(map[types.JobType]JobType)
+)
+
+// RegisterJobType makes a job type available under name. It panics if the
+// name is empty or already registered, as registration happens at init.
+func RegisterJobType(name types.JobType, jt JobType) {
+	jobTypesMu.Lock()
+	defer jobTypesMu.Unlock()
+
+	if name == "" || jt == nil {
+		panic("fuzzer: RegisterJobType needs a name and a job type")
+	}
+	if _, exists := jobTypes[name]; exists {
+		panic(fmt.Sprintf("fuzzer: job type %q registered twice", name))
+	}
+	jobTypes[name] = jt
+}
+
+// JobTypes returns the names of the registered job types, sorted
+func JobTypes() []types.JobType {
+	jobTypesMu.RLock()
+	defer jobTypesMu.RUnlock()
+
+	names := make([]types.JobType, 0, len(jobTypes))
+	for name := range jobTypes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// lookupJobType returns the job type registered under name
+func lookupJobType(name types.JobType) (JobType, error) {
+	jobTypesMu.RLock()
+	jt, exists := jobTypes[name]
+	jobTypesMu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("%w: unknown job type %q", types.ErrInvalidJob, name)
+	}
+	return jt, nil
+}
@@ -0,0 +1,111 @@
+package fuzzer
+
+import (
+	"net/url"
+	"strings"
+
+	"fuzzer/internal/crawl"
+	"fuzzer/types"
+)
+
+const (
+	defaultSpiderDepth = 2
+	defaultSpiderPages = 100
+)
+
+// spiderLink is a path the spider queued and the page it was linked from
+type spiderLink struct {
+	payload string
+	from    string
+	depth   int
+}
+
+// spider queues the links in a job's findings, and the directories above
+// them, as payloads for the job
+type spider struct {
+	linker Linker
+	// follow is false when the spider only queues the paths fed to it,
+	// such as those found in scripts
+	follow   bool
+	maxDepth int
+	maxPages int
+	queue    []spiderLink
+	seen     map[string]bool
+	queued   int
+}
+
+// newSpider returns the spider for a job, or nil if the job neither asks
+// for one nor feeds it the paths in scripts, or its type can't follow links
+func newSpider(job *types.Job, jt JobType) *spider {
+	linker, ok := jt.(Linker)
+	feed := job.Options.JS != nil && job.Options.JS.FeedPaths
+	if !ok || job.Options.Spider == nil && !feed {
+		return nil
+	}
+	opts := job.Options.Spider
+	if opts == nil {
+		opts = &types.SpiderOptions{}
+	}
+	s := &spider{
+		linker:   linker,
+		follow:   job.Options.Spider != nil,
+		maxDepth: opts.MaxDepth,
+		maxPages: opts.MaxPages,
+		seen:     make(map[string]bool),
+	}
+	if s.maxDepth <= 0 {
+		s.maxDepth = defaultSpiderDepth
+	}
+	if s.maxPages <= 0 {
+		s.maxPages = defaultSpiderPages
+	}
+	return s
+}
+
+// visit queues the links in the response of a finding for payload
+func (s *spider) visit(job *types.Job, payload string, resp *Response, depth int) {
+	s.seen[payload] = true
+	if !s.follow {
+		return
+	}
+	links := crawl.Links(resp.Request.URL, resp.Header.Get("Content-Type"), resp.Body)
+	s.feed(job, links, resp.Request.URL.String(), depth)
+}
+
+// feed queues links found at depth in from, and the directories above
+// them, that the job's type can send
+func (s *spider) feed(job *types.Job, links []*url.URL, from string, depth int) {
+	if depth >= s.maxDepth {
+		return
+	}
+	for _, link := range links {
+		linked, ok := s.linker.Payload(job, link)
+		if !ok {
+			continue
+		}
+		dirs := strings.Split(linked, "/")
+		for i := 1; i < len(dirs); i++ {
+			s.add(strings.Join(dirs[:i], "/")+"/", from, depth+1)
+		}
+		s.add(linked, from, depth+1)
+	}
+}
+
+func (s *spider) add(payload, from string, depth int) {
+	if s.seen[payload] || s.queued >= s.maxPages {
+		return
+	}
+	s.seen[payload] = true
+	s.queued++
+	s.queue = append(s.queue, spiderLink{payload: payload, from: from, depth: depth})
+}
+
+// next takes the oldest queued link
+func (s *spider) next() (spiderLink, bool) {
+	if len(s.queue) == 0 {
+		return spiderLink{}, false
+	}
+	link := s.queue[0]
+	s.queue = s.queue[1:]
+	return link, true
+}
@@ -0,0 +1,148 @@
+package fuzzer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"fuzzer/internal/logging"
+	"fuzzer/types"
+)
+
+// verbMethods are compared with GET when a verb job doesn't list its own
+// methods. FUZZ stands in for the made-up verbs some servers route anyway.
+var verbMethods = []string{"HEAD", "POST", "PUT", "DELETE", "PATCH", "OPTIONS", "TRACE", "FUZZ"}
+
+// overrideHeaders are the headers frameworks read to let a POST stand in
+// for another method
+var overrideHeaders = []string{"X-HTTP-Method-Override", "X-HTTP-Method", "X-Method-Override"}
+
+// verbType requests each payload as a path under the target with every
+// method, and as a POST overriding its method through each override header,
+// and reports the responses that differ from a plain GET or POST
+type verbType struct{}
+
+func (verbType) Validate(job *types.Job) error {
+	if _, err := parseTarget(job); err != nil {
+		return err
+	}
+	return nil
+}
+
+// BuildRequests returns the GET request the other methods are compared with
+func (verbType) BuildRequests(ctx context.Context, job *types.Job, payload string) ([]*http.Request, error) {
+	return directoryType{}.BuildRequests(ctx, job, payload)
+}
+
+// Judge isn't called, as verb findings come from comparing responses
+func (verbType) Judge(job *types.Job, resp *Response) (string, bool) {
+	return resp.Request.URL.String(), false
+}
+
+func (v verbType) Probe(ctx context.Context, job *types.Job, payload string, send SendFunc) []Hit {
+	request := func(method, header, override string) (*Response, error) {
+		reqs, err := v.BuildRequests(ctx, job, payload)
+		if err != nil {
+			return nil, err
+		}
+		req := reqs[0]
+		req.Method = method
+		if header != "" {
+			req.Header.Set(header, override)
+		}
+		return send(req)
+	}
+
+	get, err := request("GET", "", "")
+	if err != nil {
+		logging.Debug("Verb baseline failed for %q: %v", payload, err)
+		return nil
+	}
+
+	methods := job.Options.Methods
+	if len(methods) == 0 {
+		methods = verbMethods
+	}
+	var hits []Hit
+	var post *Response
+	for _, method := range methods {
+		if method == "GET" {
+			continue
+		}
+		resp, err := request(method, "", "")
+		if err != nil {
+			logging.Debug("Request failed: %s %q: %v", method, payload, err)
+			continue
+		}
+		if method == "POST" {
+			post = resp
+		}
+		if detail := difference(method, resp, "GET", get); detail != "" {
+			hits = append(hits, Hit{Response: resp, Finding: types.Finding{
+				URL:    resp.Request.URL.String(),
+				Method: method,
+				Detail: detail,
+			}})
+		}
+	}
+
+	// Overrides are compared with a plain POST, so a difference is down to
+	// the header being honoured
+	if post == nil {
+		if post, err = request("POST", "", ""); err != nil {
+			logging.Debug("Verb baseline failed for POST %q: %v", payload, err)
+			return hits
+		}
+	}
+	overrides := methods
+	if !slices.Contains(overrides, "GET") {
+		overrides = append([]string{"GET"}, overrides...)
+	}
+	for _, method := range overrides {
+		if method == "POST" {
+			continue
+		}
+		for _, header := range overrideHeaders {
+			resp, err := request("POST", header, method)
+			if err != nil {
+				logging.Debug("Request failed: POST %q with %s: %v", payload, header, err)
+				continue
+			}
+			label := fmt.Sprintf("POST with %s: %s", header, method)
+			if detail := difference(label, resp, "POST", post); detail != "" {
+				hits = append(hits, Hit{Response: resp, Finding: types.Finding{
+					URL:    resp.Request.URL.String(),
+					Method: "POST",
+					Detail: detail,
+				}})
+			}
+		}
+	}
+	return hits
+}
+
+// difference describes how resp, sent as what, was answered differently
+// from base, sent as baseWhat, or returns "" if it wasn't. Responses are
+// fingerprinted as parameter discovery does, and their Allow headers
+// compared too. A method being refused with 405 or 501 isn't counted.
+// Bodies are only compared when both responses have one, as answers to
+// HEAD and OPTIONS don't, and OPTIONS is expected to answer with Allow.
+func difference(what string, resp *Response, baseWhat string, base *Response) string {
+	if resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented {
+		return ""
+	}
+	got, want := fingerprintOf(resp), fingerprintOf(base)
+	allow, baseAllow := resp.Header.Get("Allow"), base.Header.Get("Allow")
+	switch {
+	case got.status != want.status:
+		return fmt.Sprintf("%s returned %d, %s returned %d", what, got.status, baseWhat, want.status)
+	case got.location != want.location:
+		return fmt.Sprintf("%s redirects to %q, %s to %q", what, got.location, baseWhat, want.location)
+	case allow != baseAllow && resp.Request.Method != http.MethodOptions:
+		return fmt.Sprintf("%s allows %q, %s allows %q", what, allow, baseWhat, baseAllow)
+	case len(resp.Body) > 0 && len(base.Body) > 0 && (got.lines != want.lines || got.words != want.words):
+		return fmt.Sprintf("%s returned %d lines and %d words, %s returned %d and %d", what, got.lines, got.words, baseWhat, want.lines, want.words)
+	}
+	return ""
+}
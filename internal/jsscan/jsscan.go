@@ -0,0 +1,167 @@
+// Package jsscan extracts the endpoints and likely secrets a JavaScript
+// file gives away: URL paths and API routes in its string literals, keys
+// and tokens in known formats, and high-entropy strings assigned to names
+// such as apiKey or secret.
+package jsscan
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"fuzzer/internal/crawl"
+)
+
+// Kind is what an item is
+type Kind string
+
+const (
+	Endpoint Kind = "endpoint"
+	Secret   Kind = "secret"
+)
+
+// Item is an endpoint or secret found in a script
+type Item struct {
+	Kind Kind
+	// Value is the endpoint as written, or the secret
+	Value string
+	// Rule names the pattern that found a secret
+	Rule string
+	// Line is the 1-based line of the script the value is on
+	Line int
+}
+
+const (
+	// minSecretEntropy is the Shannon entropy, in bits per character, a
+	// value assigned to a secret-sounding name needs to count
+	minSecretEntropy = 3.5
+	// minLooseEntropy is what a string literal needs to count as a secret
+	// on its entropy alone
+	minLooseEntropy = 4.5
+)
+
+// assignRe matches a secret-sounding name given a quoted value
+var assignRe = regexp.MustCompile(`(?i)\b([A-Za-z0-9_]*(?:api[_-]?key|secret|token|passw(?:or)?d|auth|access[_-]?key|private[_-]?key|client[_-]?id))["']?\s*[:=]\s*["']([^"'\s]{8,})["']`)
+
+// secretRules are the formats of well-known keys and tokens
+var secretRules = []struct {
+	name string
+	re   *regexp.Regexp
+}{
+	{"AWS access key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"GitHub token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`)},
+	{"Stripe secret key", regexp.MustCompile(`\b[sr]k_live_[0-9A-Za-z]{24,}\b`)},
+	{"JSON web token", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{"private key", regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH )?PRIVATE KEY-----`)},
+}
+
+// Scan returns the endpoints and secrets in a script, each value once, in
+// the order they appear
+func Scan(src []byte) []Item {
+	s := &scanner{src: string(src), seen: make(map[string]bool)}
+	s.lines = lineStarts(s.src)
+
+	for _, rule := range secretRules {
+		for _, loc := range rule.re.FindAllStringIndex(s.src, -1) {
+			s.add(Item{Kind: Secret, Value: s.src[loc[0]:loc[1]], Rule: rule.name}, loc[0])
+		}
+	}
+	for _, m := range assignRe.FindAllStringSubmatchIndex(s.src, -1) {
+		value := s.src[m[4]:m[5]]
+		if Entropy(value) >= minSecretEntropy && !crawl.IsScriptLink(value) {
+			s.add(Item{Kind: Secret, Value: value, Rule: "assigned to " + s.src[m[2]:m[3]]}, m[4])
+		}
+	}
+	// Endpoints are the literals the spider would follow
+	for _, str := range crawl.ScriptStrings(s.src) {
+		switch {
+		case crawl.IsScriptLink(str.Value):
+			s.add(Item{Kind: Endpoint, Value: str.Value}, str.Offset)
+		case looksRandom(str.Value):
+			s.add(Item{Kind: Secret, Value: str.Value, Rule: "high entropy string"}, str.Offset)
+		}
+	}
+
+	sort.SliceStable(s.found, func(i, j int) bool { return s.found[i].offset < s.found[j].offset })
+	items := make([]Item, len(s.found))
+	for i, f := range s.found {
+		items[i] = f.Item
+	}
+	return items
+}
+
+type scanner struct {
+	src   string
+	lines []int
+	seen  map[string]bool
+	found []found
+}
+
+// found is an item and the offset it was found at
+type found struct {
+	Item
+	offset int
+}
+
+// add records an item found at offset, unless its value was already found
+func (s *scanner) add(item Item, offset int) {
+	if s.seen[item.Value] {
+		return
+	}
+	s.seen[item.Value] = true
+	item.Line = sort.SearchInts(s.lines, offset+1)
+	s.found = append(s.found, found{Item: item, offset: offset})
+}
+
+// lineStarts returns the offset each line starts at
+func lineStarts(src string) []int {
+	starts := []int{0}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// looksRandom reports whether a string literal is shaped like a key: of
+// key length, without spaces, mixing cases and digits, and high in entropy
+func looksRandom(value string) bool {
+	if len(value) < 24 || len(value) > 64 || strings.ContainsAny(value, " /.\\") {
+		return false
+	}
+	var upper, lower, digit bool
+	for _, c := range value {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= '0' && c <= '9':
+			digit = true
+		}
+	}
+	return upper && lower && digit && Entropy(value) >= minLooseEntropy
+}
+
+// Entropy returns the Shannon entropy of s in bits per character
+func Entropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	n := 0
+	for _, c := range s {
+		counts[c]++
+		n++
+	}
+	var h float64
+	for _, count := range counts {
+		p := float64(count) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 )
 
 var (
@@ -13,6 +14,8 @@ var (
 	ErrorLogger *log.Logger
 	// DebugLogger logs debug information
 	DebugLogger *log.Logger
+	// AuditLogger logs security-relevant events such as scope violations
+	AuditLogger *log.Logger
 )
 
 // LogLevel defines the logging verbosity
@@ -35,10 +38,13 @@ func InitLogger(level LogLevel) {
 
 	InfoLogger = stdOut
 	ErrorLogger = stdErr
+	AuditLogger = log.New(os.Stdout, "AUDIT: ", log.Ldate|log.Ltime|log.LUTC)
 
 	// Only create debug logger if debug level is set
 	if level == LevelDebug {
 		DebugLogger = log.New(os.Stdout, "DEBUG: ", log.Ldate|log.Ltime|log.Lshortfile)
+	} else {
+		DebugLogger = nil
 	}
 }
 
@@ -56,9 +62,28 @@ func Error(format string, v ...interface{}) {
 	}
 }
 
+// Audit logs security-relevant events regardless of the log level
+func Audit(format string, v ...interface{}) {
+	if AuditLogger != nil {
+		AuditLogger.Output(2, fmt.Sprintf(format, v...))
+	}
+}
+
 // Debug logs debug messages (only when debug level is set)
 func Debug(format string, v ...interface{}) {
 	if DebugLogger != nil {
 		DebugLogger.Output(2, fmt.Sprintf(format, v...))
 	}
 }
+
+// ParseLevel converts a level name ("info" or "debug") into a LogLevel
+func ParseLevel(name string) (LogLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "info", "":
+		return LevelInfo, nil
+	case "debug":
+		return LevelDebug, nil
+	default:
+		return LevelInfo, fmt.Errorf("unknown log level %q (expected \"info\" or \"debug\")", name)
+	}
+}
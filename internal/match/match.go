@@ -0,0 +1,82 @@
+// Package match compiles and evaluates the expressions that decide which
+// responses are findings, such as
+//
+//	status in [200, 403] && !body.contains("Not Found") && len(body) > 1200
+//
+// Expressions are type checked when they are compiled, so a job with a
+// broken expression is refused before it sends anything.
+//
+// Variables:
+//
+//	status          int      response status code
+//	body            string   response body, as far as it was read
+//	length          int      length of body in bytes
+//	lines, words    int      number of lines and of whitespace-separated words
+//	header["Name"]  string   response header, case-insensitive; "" if absent
+//	time            int      response time in milliseconds
+//	url, method     string   the request's URL and method
+//	found           bool     the job type's own verdict
+//
+// Operators, loosest first: ||, &&, !, then == != < <= > >= in matches
+// contains. "x" in ["a", "b"] tests list membership and "Server" in header
+// tests for a header. matches takes a regular expression, which must be a
+// literal so it is compiled once.
+//
+// Functions: len(string or list), lower(s), upper(s). Strings also have the
+// methods s.contains(x), s.startsWith(x), s.endsWith(x), s.matches(re),
+// s.lower() and s.upper().
+package match
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Response is what an expression is evaluated against
+type Response struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Duration   time.Duration
+	// Found is the job type's own verdict on the response
+	Found bool
+}
+
+// Expr is a compiled expression
+type Expr struct {
+	src  string
+	root *node
+}
+
+// Compile parses and type checks an expression, which must be boolean
+func Compile(src string) (*Expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, fmt.Errorf("match %q: %w", src, err)
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseExpr()
+	if err == nil && p.peek().kind != tokEOF {
+		err = p.errorf("unexpected %q", p.peek().text)
+	}
+	if err == nil && root.kind != kindBool {
+		err = fmt.Errorf("expression is %s, not bool", root.kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("match %q: %w", src, err)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// String returns the expression's source
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Match evaluates the expression against a response
+func (e *Expr) Match(resp *Response) bool {
+	return e.root.eval(newEnv(resp)).(bool)
+}
@@ -0,0 +1,80 @@
+package match
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	resp := &Response{
+		Method:     "GET",
+		URL:        "http://example.com/admin/",
+		StatusCode: 403,
+		Header:     http.Header{"Server": {"nginx/1.25"}, "Content-Type": {"text/html"}},
+		Body:       []byte("<h1>Forbidden</h1>\nYou don't have access\n"),
+		Duration:   250 * time.Millisecond,
+		Found:      true,
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`status in [200, 403] && !body.contains("Not Found") && len(body) > 20 && header["Server"] matches "nginx"`, true},
+		{`status == 200`, false},
+		{`status != 200 && status >= 400 && status < 500`, true},
+		{`length == 41 && lines == 2 && words == 5`, true},
+		{`header["server"] == "nginx/1.25"`, true},
+		{`header["X-Missing"] == ""`, true},
+		{`"Server" in header && !("X-Missing" in header)`, true},
+		{`body contains "Forbidden"`, true},
+		{`lower(body).contains("forbidden") && body.upper().startsWith("<H1>")`, true},
+		{`url.endsWith("/admin/") && method == 'GET'`, true},
+		{`time > 200 && time <= 250`, true},
+		{`found && !false`, true},
+		{`body.matches("(?i)forbidden") || status == 200`, true},
+		{`method in ["POST", "PUT"]`, false},
+		{`["a", "b"][1] == "b" && len([1, 2, 3]) == 3`, true},
+		{`status == 404 || status == 403 && false`, false},
+	}
+	for _, tt := range tests {
+		expr, err := Compile(tt.expr)
+		if assert.NoError(t, err, tt.expr) {
+			assert.Equal(t, tt.want, expr.Match(resp), tt.expr)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{``, "expected a value"},
+		{`status`, "expression is int, not bool"},
+		{`status == "200"`, "can't compare int == string"},
+		{`body > 10`, "> needs int operands"},
+		{`status in ["200"]`, "in can't look for int in list"},
+		{`[1, "a"]`, "list mixes int and string"},
+		{`body matches header["X"]`, "string literal pattern"},
+		{`body matches "("`, "invalid pattern"},
+		{`bodyy == ""`, "unknown variable bodyy"},
+		{`body.has("x")`, "unknown function has"},
+		{`len(body, url) > 0`, "wrong number of arguments to len"},
+		{`status == 200 &&`, "end of expression"},
+		{`(status == 200`, `expected ")"`},
+		{`status == 200 200`, `position 15: unexpected "200"`},
+		{`body.contains("x`, "unterminated string"},
+		{`status # 1`, "unexpected character"},
+		{`!status`, "! needs bool operands"},
+	}
+	for _, tt := range tests {
+		_, err := Compile(tt.expr)
+		if assert.Error(t, err, tt.expr) {
+			assert.Contains(t, err.Error(), tt.err, tt.expr)
+		}
+	}
+}
@@ -0,0 +1,536 @@
+package match
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// kind is the static type of an expression
+type kind int
+
+const (
+	kindBool kind = iota
+	kindInt
+	kindString
+	kindList
+	kindHeaders
+)
+
+func (k kind) String() string {
+	switch k {
+	case kindBool:
+		return "bool"
+	case kindInt:
+		return "int"
+	case kindString:
+		return "string"
+	case kindList:
+		return "list"
+	}
+	return "header"
+}
+
+// node is a type-checked expression. Values are bool, int64, string,
+// []any or http.Header according to kind.
+type node struct {
+	kind kind
+	// elem is the kind of a list's elements
+	elem kind
+	eval func(*env) any
+	// literal is set for string literals, so patterns can be compiled once
+	literal *string
+}
+
+// env is the response an expression is evaluated against, with the values
+// derived from the body worked out when first needed
+type env struct {
+	resp  *Response
+	body  *string
+	lines int
+	words int
+	split bool
+}
+
+func newEnv(resp *Response) *env {
+	return &env{resp: resp}
+}
+
+func (e *env) bodyString() string {
+	if e.body == nil {
+		s := string(e.resp.Body)
+		e.body = &s
+	}
+	return *e.body
+}
+
+func (e *env) counts() (int, int) {
+	if !e.split {
+		e.split = true
+		e.lines = bytes.Count(e.resp.Body, []byte{'\n'})
+		if len(e.resp.Body) > 0 && !bytes.HasSuffix(e.resp.Body, []byte{'\n'}) {
+			e.lines++
+		}
+		e.words = len(bytes.Fields(e.resp.Body))
+	}
+	return e.lines, e.words
+}
+
+// variables are the names an expression can refer to
+var variables = map[string]*node{
+	"status": {kind: kindInt, eval: func(e *env) any { return int64(e.resp.StatusCode) }},
+	"body":   {kind: kindString, eval: func(e *env) any { return e.bodyString() }},
+	"length": {kind: kindInt, eval: func(e *env) any { return int64(len(e.resp.Body)) }},
+	"lines": {kind: kindInt, eval: func(e *env) any {
+		lines, _ := e.counts()
+		return int64(lines)
+	}},
+	"words": {kind: kindInt, eval: func(e *env) any {
+		_, words := e.counts()
+		return int64(words)
+	}},
+	"header": {kind: kindHeaders, eval: func(e *env) any { return e.resp.Header }},
+	"time":   {kind: kindInt, eval: func(e *env) any { return e.resp.Duration.Milliseconds() }},
+	"url":    {kind: kindString, eval: func(e *env) any { return e.resp.URL }},
+	"method": {kind: kindString, eval: func(e *env) any { return e.resp.Method }},
+	"found":  {kind: kindBool, eval: func(e *env) any { return e.resp.Found }},
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is the given punctuation or keyword
+func (p *parser) accept(text string) bool {
+	t := p.peek()
+	if (t.kind == tokPunct || t.kind == tokIdent) && t.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return p.errorf("expected %q", text)
+	}
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	t := p.peek()
+	if t.kind == tokEOF {
+		return fmt.Errorf("end of expression: "+format, args...)
+	}
+	return fmt.Errorf("position %d: "+format, append([]any{t.pos + 1}, args...)...)
+}
+
+func (p *parser) parseExpr() (*node, error) {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (*node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if err := checkBool("||", left, right); err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = &node{kind: kindBool, eval: func(e *env) any { return l.eval(e).(bool) || r.eval(e).(bool) }}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (*node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if err := checkBool("&&", left, right); err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = &node{kind: kindBool, eval: func(e *env) any { return l.eval(e).(bool) && r.eval(e).(bool) }}
+	}
+	return left, nil
+}
+
+func checkBool(op string, operands ...*node) error {
+	for _, n := range operands {
+		if n.kind != kindBool {
+			return fmt.Errorf("%s needs bool operands, not %s", op, n.kind)
+		}
+	}
+	return nil
+}
+
+func (p *parser) parseUnary() (*node, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if err := checkBool("!", operand); err != nil {
+			return nil, err
+		}
+		return &node{kind: kindBool, eval: func(e *env) any { return !operand.eval(e).(bool) }}, nil
+	}
+	return p.parseComparison()
+}
+
+// comparisons are the binary operators binding tighter than && and ||
+var comparisons = []string{"==", "!=", "<=", ">=", "<", ">", "in", "matches", "contains"}
+
+func (p *parser) parseComparison() (*node, error) {
+	left, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range comparisons {
+		if p.accept(op) {
+			right, err := p.parsePostfix()
+			if err != nil {
+				return nil, err
+			}
+			return compare(op, left, right)
+		}
+	}
+	return left, nil
+}
+
+func compare(op string, left, right *node) (*node, error) {
+	switch op {
+	case "==", "!=":
+		if left.kind != right.kind || left.kind == kindList || left.kind == kindHeaders {
+			return nil, fmt.Errorf("can't compare %s %s %s", left.kind, op, right.kind)
+		}
+		negate := op == "!="
+		return &node{kind: kindBool, eval: func(e *env) any {
+			return (left.eval(e) == right.eval(e)) != negate
+		}}, nil
+
+	case "<", "<=", ">", ">=":
+		if left.kind != kindInt || right.kind != kindInt {
+			return nil, fmt.Errorf("%s needs int operands, not %s and %s", op, left.kind, right.kind)
+		}
+		return &node{kind: kindBool, eval: func(e *env) any {
+			l, r := left.eval(e).(int64), right.eval(e).(int64)
+			switch op {
+			case "<":
+				return l < r
+			case "<=":
+				return l <= r
+			case ">":
+				return l > r
+			}
+			return l >= r
+		}}, nil
+
+	case "in":
+		return contains(right, left, "in")
+
+	case "contains":
+		return contains(left, right, "contains")
+
+	case "matches":
+		return matches(left, right)
+	}
+	return nil, fmt.Errorf("unknown operator %s", op)
+}
+
+// contains tests whether haystack, a string, list or headers, contains
+// needle
+func contains(haystack, needle *node, op string) (*node, error) {
+	switch {
+	case haystack.kind == kindString && needle.kind == kindString:
+		return &node{kind: kindBool, eval: func(e *env) any {
+			return strings.Contains(haystack.eval(e).(string), needle.eval(e).(string))
+		}}, nil
+	case haystack.kind == kindList && needle.kind == haystack.elem:
+		return &node{kind: kindBool, eval: func(e *env) any {
+			return slices.Contains(haystack.eval(e).([]any), needle.eval(e))
+		}}, nil
+	case haystack.kind == kindHeaders && needle.kind == kindString:
+		return &node{kind: kindBool, eval: func(e *env) any {
+			_, exists := haystack.eval(e).(http.Header)[http.CanonicalHeaderKey(needle.eval(e).(string))]
+			return exists
+		}}, nil
+	}
+	return nil, fmt.Errorf("%s can't look for %s in %s", op, needle.kind, haystack.kind)
+}
+
+func matches(subject, pattern *node) (*node, error) {
+	if subject.kind != kindString {
+		return nil, fmt.Errorf("matches needs a string, not %s", subject.kind)
+	}
+	if pattern.literal == nil {
+		return nil, fmt.Errorf("matches needs a string literal pattern")
+	}
+	re, err := regexp.Compile(*pattern.literal)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %v", err)
+	}
+	return &node{kind: kindBool, eval: func(e *env) any { return re.MatchString(subject.eval(e).(string)) }}, nil
+}
+
+// parsePostfix parses an operand followed by any indexes and method calls
+func (p *parser) parsePostfix() (*node, error) {
+	n, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.accept("["):
+			index, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			if n, err = indexNode(n, index); err != nil {
+				return nil, err
+			}
+
+		case p.accept("."):
+			name := p.next()
+			if name.kind != tokIdent {
+				return nil, p.errorf("expected a method name")
+			}
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			if n, err = call(name.text, append([]*node{n}, args...)); err != nil {
+				return nil, err
+			}
+
+		default:
+			return n, nil
+		}
+	}
+}
+
+func indexNode(n, index *node) (*node, error) {
+	switch {
+	case n.kind == kindHeaders && index.kind == kindString:
+		return &node{kind: kindString, eval: func(e *env) any {
+			return n.eval(e).(http.Header).Get(index.eval(e).(string))
+		}}, nil
+	case n.kind == kindList && index.kind == kindInt:
+		zero := zeroValue(n.elem)
+		return &node{kind: n.elem, eval: func(e *env) any {
+			list, i := n.eval(e).([]any), index.eval(e).(int64)
+			if i < 0 || i >= int64(len(list)) {
+				return zero
+			}
+			return list[i]
+		}}, nil
+	}
+	return nil, fmt.Errorf("can't index %s with %s", n.kind, index.kind)
+}
+
+func zeroValue(k kind) any {
+	switch k {
+	case kindBool:
+		return false
+	case kindInt:
+		return int64(0)
+	case kindString:
+		return ""
+	}
+	return nil
+}
+
+// parseArgs parses a parenthesized argument list
+func (p *parser) parseArgs() ([]*node, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var args []*node
+	if p.accept(")") {
+		return args, nil
+	}
+	for {
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.accept(")") {
+			return args, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// call builds a function call; methods are calls with the receiver first
+func call(name string, args []*node) (*node, error) {
+	argc := map[string]int{
+		"len": 1, "lower": 1, "upper": 1,
+		"contains": 2, "startsWith": 2, "endsWith": 2, "matches": 2,
+	}
+	want, known := argc[name]
+	if !known {
+		return nil, fmt.Errorf("unknown function %s", name)
+	}
+	if len(args) != want {
+		return nil, fmt.Errorf("wrong number of arguments to %s", name)
+	}
+
+	switch name {
+	case "len":
+		arg := args[0]
+		switch arg.kind {
+		case kindString:
+			return &node{kind: kindInt, eval: func(e *env) any { return int64(len(arg.eval(e).(string))) }}, nil
+		case kindList:
+			return &node{kind: kindInt, eval: func(e *env) any { return int64(len(arg.eval(e).([]any))) }}, nil
+		}
+		return nil, fmt.Errorf("len needs a string or list, not %s", arg.kind)
+	case "lower", "upper":
+		arg := args[0]
+		if arg.kind != kindString {
+			return nil, fmt.Errorf("%s needs a string, not %s", name, arg.kind)
+		}
+		conv := strings.ToLower
+		if name == "upper" {
+			conv = strings.ToUpper
+		}
+		return &node{kind: kindString, eval: func(e *env) any { return conv(arg.eval(e).(string)) }}, nil
+	case "contains":
+		return contains(args[0], args[1], name)
+	case "matches":
+		return matches(args[0], args[1])
+	}
+
+	// startsWith and endsWith
+	s, affix := args[0], args[1]
+	if s.kind != kindString || affix.kind != kindString {
+		return nil, fmt.Errorf("%s needs strings, not %s and %s", name, s.kind, affix.kind)
+	}
+	test := strings.HasPrefix
+	if name == "endsWith" {
+		test = strings.HasSuffix
+	}
+	return &node{kind: kindBool, eval: func(e *env) any { return test(s.eval(e).(string), affix.eval(e).(string)) }}, nil
+}
+
+func (p *parser) parsePrimary() (*node, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokInt:
+		p.next()
+		return &node{kind: kindInt, eval: func(*env) any { return t.num }}, nil
+
+	case tokString:
+		p.next()
+		return &node{kind: kindString, eval: func(*env) any { return t.str }, literal: &t.str}, nil
+
+	case tokIdent:
+		p.next()
+		switch t.text {
+		case "true", "false":
+			value := t.text == "true"
+			return &node{kind: kindBool, eval: func(*env) any { return value }}, nil
+		}
+		if p.peek().kind == tokPunct && p.peek().text == "(" {
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			return call(t.text, args)
+		}
+		if v, known := variables[t.text]; known {
+			return v, nil
+		}
+		p.pos--
+		return nil, p.errorf("unknown variable %s", t.text)
+
+	case tokPunct:
+		switch t.text {
+		case "(":
+			p.next()
+			n, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		case "[":
+			p.next()
+			return p.parseList()
+		}
+	}
+	if t.kind == tokEOF {
+		return nil, p.errorf("expected a value")
+	}
+	return nil, p.errorf("unexpected %q", t.text)
+}
+
+// parseList parses a list literal after its opening bracket. The elements
+// must all be the same kind.
+func (p *parser) parseList() (*node, error) {
+	var elems []*node
+	for !p.accept("]") {
+		if len(elems) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		elem, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if elem.kind == kindList || elem.kind == kindHeaders {
+			return nil, fmt.Errorf("lists can't hold %s values", elem.kind)
+		}
+		if len(elems) > 0 && elem.kind != elems[0].kind {
+			return nil, fmt.Errorf("list mixes %s and %s values", elems[0].kind, elem.kind)
+		}
+		elems = append(elems, elem)
+	}
+
+	n := &node{kind: kindList, elem: kindInt}
+	if len(elems) > 0 {
+		n.elem = elems[0].kind
+	}
+	n.eval = func(e *env) any {
+		values := make([]any, len(elems))
+		for i, elem := range elems {
+			values[i] = elem.eval(e)
+		}
+		return values
+	}
+	return n, nil
+}
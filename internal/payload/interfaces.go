@@ -0,0 +1,6 @@
+package payload
+
+// Processor transforms a payload before it is put into a request
+type Processor interface {
+	Process(payload string) string
+}
@@ -0,0 +1,95 @@
+package payload
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+)
+
+// processorFunc adapts a function to the Processor interface
+type processorFunc func(string) string
+
+func (f processorFunc) Process(payload string) string {
+	return f(payload)
+}
+
+// processors are the named processors that take no argument
+var processors = map[string]processorFunc{
+	"url":        url.QueryEscape,
+	"url-path":   url.PathEscape,
+	"url-all":    encodeAll,
+	"double-url": func(s string) string { return url.QueryEscape(url.QueryEscape(s)) },
+	"base64":     func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"base64url":  func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) },
+	"hex":        func(s string) string { return hex.EncodeToString([]byte(s)) },
+	"html":       html.EscapeString,
+	"md5":        func(s string) string { sum := md5.Sum([]byte(s)); return hex.EncodeToString(sum[:]) },
+	"sha1":       func(s string) string { sum := sha1.Sum([]byte(s)); return hex.EncodeToString(sum[:]) },
+	"sha256":     func(s string) string { sum := sha256.Sum256([]byte(s)); return hex.EncodeToString(sum[:]) },
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+}
+
+// encodeAll percent-encodes every byte, not just the reserved ones
+func encodeAll(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		fmt.Fprintf(&b, "%%%02X", s[i])
+	}
+	return b.String()
+}
+
+// Chain is an ordered list of processors, each applied to the output of the
+// one before
+type Chain []Processor
+
+// Parse parses processor specs into a chain. The processors are:
+//
+//	url, url-path     percent-encode for a query value or a path segment
+//	url-all           percent-encode every byte
+//	double-url        percent-encode twice
+//	base64, base64url standard and unpadded URL-safe base64
+//	hex               hex-encode the bytes
+//	html              escape HTML special characters
+//	md5, sha1, sha256 hex digest
+//	lower, upper      change case
+//	prefix:TEXT       prepend TEXT
+//	suffix:TEXT       append TEXT
+func Parse(specs []string) (Chain, error) {
+	chain := make(Chain, 0, len(specs))
+	for _, spec := range specs {
+		name, arg, hasArg := strings.Cut(strings.TrimSpace(spec), ":")
+		switch name {
+		case "prefix":
+			chain = append(chain, processorFunc(func(s string) string { return arg + s }))
+			continue
+		case "suffix":
+			chain = append(chain, processorFunc(func(s string) string { return s + arg }))
+			continue
+		}
+
+		p, ok := processors[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown processor %q", spec)
+		}
+		if hasArg {
+			return nil, fmt.Errorf("processor %q takes no argument", name)
+		}
+		chain = append(chain, p)
+	}
+	return chain, nil
+}
+
+// Apply runs payload through every processor in order
+func (c Chain) Apply(payload string) string {
+	for _, p := range c {
+		payload = p.Process(payload)
+	}
+	return payload
+}
@@ -0,0 +1,43 @@
+package payload
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessors(t *testing.T) {
+	tests := []struct {
+		specs []string
+		in    string
+		want  string
+	}{
+		{nil, "a b", "a b"},
+		{[]string{"url"}, "a b&c", "a+b%26c"},
+		{[]string{"url-path"}, "a b/c", "a%20b%2Fc"},
+		{[]string{"url-all"}, "ab", "%61%62"},
+		{[]string{"double-url"}, "a/b", "a%252Fb"},
+		{[]string{"base64"}, "admin", "YWRtaW4="},
+		{[]string{"base64url"}, "\xfb\xff", "-_8"},
+		{[]string{"hex"}, "ab", "6162"},
+		{[]string{"html"}, "<a>", "&lt;a&gt;"},
+		{[]string{"md5"}, "admin", "21232f297a57a5a743894a0e4a801fc3"},
+		{[]string{"sha1"}, "admin", "d033e22ae348aeb5660fc2140aec35850c4da997"},
+		{[]string{"sha256"}, "admin", "8c6976e5b5410415bde908bd4dee15dfb167a9c873fc4bb8a81f6f2ab448a918"},
+		{[]string{"upper"}, "admin", "ADMIN"},
+		// Processors run in order
+		{[]string{"prefix:{\"user\":\"", "suffix:\"}", "base64"}, "admin", "eyJ1c2VyIjoiYWRtaW4ifQ=="},
+		{[]string{"base64", "url"}, "admin?", "YWRtaW4%2F"},
+	}
+	for _, tt := range tests {
+		chain, err := Parse(tt.specs)
+		if assert.NoError(t, err, tt.specs) {
+			assert.Equal(t, tt.want, chain.Apply(tt.in), tt.specs)
+		}
+	}
+
+	for _, spec := range []string{"rot13", "base64:x", ""} {
+		_, err := Parse([]string{spec})
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,283 @@
+package rules
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// maxHashcatWord is the longest word a hashcat function may produce, as in
+// hashcat. A function that would grow the word past it leaves it unchanged,
+// so rules such as "dddddddddd" can't blow words up exponentially.
+const maxHashcatWord = 256
+
+// hashcatRule applies a sequence of hashcat rule functions. Rejection
+// functions aren't supported, so every word yields exactly one variant.
+// Positions beyond the end of the word leave it unchanged, as in hashcat.
+type hashcatRule struct {
+	ops []func([]byte) []byte
+}
+
+func (r *hashcatRule) Len() int {
+	return 1
+}
+
+func (r *hashcatRule) Apply(word string, i int) string {
+	w := []byte(word)
+	for _, op := range r.ops {
+		// Functions that grow the word leave their input intact, and one
+		// call can grow it to at most 36 times maxHashcatWord
+		if grown := op(w); len(grown) <= max(len(w), maxHashcatWord) {
+			w = grown
+		}
+	}
+	return string(w)
+}
+
+// hashcatArgs lists the arguments each supported function takes: N is a
+// position, 0-9 then A-Z, and X is a literal character
+var hashcatArgs = map[byte]string{
+	':': "", 'l': "", 'u': "", 'c': "", 'C': "", 't': "", 'r': "",
+	'd': "", 'f': "", '{': "", '}': "", '[': "", ']': "", 'q': "",
+	'T': "N", 'p': "N", 'D': "N", 'z': "N", 'Z': "N", '\'': "N",
+	'$': "X", '^': "X", '@': "X",
+	'x': "NN", 'O': "NN", 'i': "NX", 'o': "NX", 's': "XX",
+}
+
+// parseHashcat parses a rule written in hashcat's rule language, such as
+// "c $1 $2" to capitalize and append 12
+func parseHashcat(rule string) (Rule, error) {
+	hc := &hashcatRule{}
+	for i := 0; i < len(rule); {
+		fn := rule[i]
+		i++
+		if fn == ' ' {
+			continue
+		}
+		spec, ok := hashcatArgs[fn]
+		if !ok {
+			return nil, fmt.Errorf("unsupported hashcat function %q", fn)
+		}
+		if i+len(spec) > len(rule) {
+			return nil, fmt.Errorf("hashcat function %q needs %d argument(s)", fn, len(spec))
+		}
+
+		args := make([]int, len(spec))
+		for j := range spec {
+			c := rule[i+j]
+			if spec[j] == 'X' {
+				args[j] = int(c)
+				continue
+			}
+			pos, ok := hashcatPosition(c)
+			if !ok {
+				return nil, fmt.Errorf("hashcat function %q: invalid position %q", fn, c)
+			}
+			args[j] = pos
+		}
+		i += len(spec)
+
+		hc.ops = append(hc.ops, hashcatOp(fn, args))
+	}
+	if len(hc.ops) == 0 {
+		return nil, fmt.Errorf("empty hashcat rule")
+	}
+	return hc, nil
+}
+
+func hashcatPosition(c byte) (int, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0'), true
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A') + 10, true
+	}
+	return 0, false
+}
+
+// The case functions only touch ASCII letters, as in hashcat, so words that
+// aren't valid UTF-8 pass through intact
+func lower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c - 'A' + 'a'
+	}
+	return c
+}
+
+func upper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
+
+func toggle(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return upper(c)
+	}
+	return lower(c)
+}
+
+// mapCase applies first to the first byte and rest to the others
+func mapCase(first, rest func(byte) byte) func([]byte) []byte {
+	return func(w []byte) []byte {
+		for i := range w {
+			if i == 0 {
+				w[i] = first(w[i])
+			} else {
+				w[i] = rest(w[i])
+			}
+		}
+		return w
+	}
+}
+
+func hashcatOp(fn byte, args []int) func([]byte) []byte {
+	switch fn {
+	case 'l':
+		return mapCase(lower, lower)
+	case 'u':
+		return mapCase(upper, upper)
+	case 'c':
+		return mapCase(upper, lower)
+	case 'C':
+		return mapCase(lower, upper)
+	case 't':
+		return mapCase(toggle, toggle)
+	case 'T':
+		return func(w []byte) []byte {
+			if args[0] < len(w) {
+				w[args[0]] = toggle(w[args[0]])
+			}
+			return w
+		}
+	case 'r':
+		return func(w []byte) []byte {
+			for i, j := 0, len(w)-1; i < j; i, j = i+1, j-1 {
+				w[i], w[j] = w[j], w[i]
+			}
+			return w
+		}
+	case 'd':
+		return func(w []byte) []byte { return append(w, w...) }
+	case 'p':
+		return func(w []byte) []byte { return bytes.Repeat(w, args[0]+1) }
+	case 'f':
+		return func(w []byte) []byte {
+			out := append([]byte{}, w...)
+			for i := len(w) - 1; i >= 0; i-- {
+				out = append(out, w[i])
+			}
+			return out
+		}
+	case '{':
+		return func(w []byte) []byte {
+			if len(w) == 0 {
+				return w
+			}
+			return append(w[1:], w[0])
+		}
+	case '}':
+		return func(w []byte) []byte {
+			if len(w) == 0 {
+				return w
+			}
+			return append([]byte{w[len(w)-1]}, w[:len(w)-1]...)
+		}
+	case '$':
+		return func(w []byte) []byte { return append(w, byte(args[0])) }
+	case '^':
+		return func(w []byte) []byte { return append([]byte{byte(args[0])}, w...) }
+	case '[':
+		return func(w []byte) []byte {
+			if len(w) == 0 {
+				return w
+			}
+			return w[1:]
+		}
+	case ']':
+		return func(w []byte) []byte {
+			if len(w) == 0 {
+				return w
+			}
+			return w[:len(w)-1]
+		}
+	case 'D':
+		return func(w []byte) []byte {
+			if args[0] >= len(w) {
+				return w
+			}
+			return append(w[:args[0]], w[args[0]+1:]...)
+		}
+	case 'x':
+		return func(w []byte) []byte {
+			start, n := args[0], args[1]
+			if start >= len(w) {
+				return w
+			}
+			return w[start:min(start+n, len(w))]
+		}
+	case 'O':
+		return func(w []byte) []byte {
+			start, n := args[0], args[1]
+			if start >= len(w) {
+				return w
+			}
+			return append(w[:start], w[min(start+n, len(w)):]...)
+		}
+	case 'i':
+		return func(w []byte) []byte {
+			if args[0] > len(w) {
+				return w
+			}
+			out := append([]byte{}, w[:args[0]]...)
+			out = append(out, byte(args[1]))
+			return append(out, w[args[0]:]...)
+		}
+	case 'o':
+		return func(w []byte) []byte {
+			if args[0] < len(w) {
+				w[args[0]] = byte(args[1])
+			}
+			return w
+		}
+	case 's':
+		return func(w []byte) []byte {
+			return bytes.ReplaceAll(w, []byte{byte(args[0])}, []byte{byte(args[1])})
+		}
+	case '@':
+		return func(w []byte) []byte {
+			return bytes.ReplaceAll(w, []byte{byte(args[0])}, nil)
+		}
+	case 'z':
+		return func(w []byte) []byte {
+			if len(w) == 0 {
+				return w
+			}
+			return append(bytes.Repeat(w[:1], args[0]), w...)
+		}
+	case 'Z':
+		return func(w []byte) []byte {
+			if len(w) == 0 {
+				return w
+			}
+			return append(w, bytes.Repeat(w[len(w)-1:], args[0])...)
+		}
+	case 'q':
+		return func(w []byte) []byte {
+			out := make([]byte, 0, len(w)*2)
+			for _, c := range w {
+				out = append(out, c, c)
+			}
+			return out
+		}
+	case '\'':
+		return func(w []byte) []byte {
+			if args[0] < len(w) {
+				return w[:args[0]]
+			}
+			return w
+		}
+	}
+	// ':' passes the word through
+	return func(w []byte) []byte { return w }
+}
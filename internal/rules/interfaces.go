@@ -0,0 +1,11 @@
+package rules
+
+// Rule derives a fixed number of variants from every word. Variants are
+// produced by index so a rule with many variants, like a number range, never
+// has to hold them all at once.
+type Rule interface {
+	// Len is the number of variants the rule produces for each word
+	Len() int
+	// Apply returns variant i of word, for 0 <= i < Len()
+	Apply(word string, i int) string
+}
@@ -0,0 +1,250 @@
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxVariants caps the variants a single rule may produce per word, to catch
+// ranges typed with a digit too many
+const maxVariants = 10_000_000
+
+// leetReplacer substitutes the common leetspeak characters
+var leetReplacer = strings.NewReplacer(
+	"a", "4", "A", "4",
+	"e", "3", "E", "3",
+	"i", "1", "I", "1",
+	"o", "0", "O", "0",
+	"s", "5", "S", "5",
+	"t", "7", "T", "7",
+)
+
+// Set is an ordered list of rules. Every word yields the variants of each
+// rule in turn; the original word is only included if the set contains the
+// "original" rule. Variants are not deduplicated, so the keyspace is exact.
+type Set struct {
+	specs []string
+	rules []Rule
+}
+
+// Parse parses rule specs. Named rules are:
+//
+//	original              the word unchanged
+//	lower, upper          change case
+//	capitalize            upper-case the first letter, lower-case the rest
+//	reverse               reverse the word
+//	leet                  a=4 e=3 i=1 o=0 s=5 t=7
+//	prefix:TEXT           prepend TEXT
+//	suffix:TEXT           append TEXT
+//	numbers:FROM-TO       append each number; a leading zero in FROM pads
+//	                      every number to its width, so 00-99 gives 00..99
+//	years:FROM-TO         append each year
+//	dates:FROM..TO[:FMT]  append each day from FROM to TO (YYYY-MM-DD),
+//	                      formatted with YYYY, YY, MM and DD (default YYYYMMDD)
+//	hc:RULE               a hashcat-style rule such as "hc:c $1 $!"
+func Parse(specs []string) (*Set, error) {
+	set := &Set{specs: specs}
+	for _, spec := range specs {
+		rule, err := parseRule(spec)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", spec, err)
+		}
+		set.rules = append(set.rules, rule)
+	}
+	return set, nil
+}
+
+// Specs returns the specs the set was parsed from
+func (s *Set) Specs() []string {
+	return s.specs
+}
+
+// Len is the number of variants produced for each word
+func (s *Set) Len() int {
+	n := 0
+	for _, r := range s.rules {
+		n += r.Len()
+	}
+	return n
+}
+
+// Rules returns the parsed rules in order
+func (s *Set) Rules() []Rule {
+	return s.rules
+}
+
+func parseRule(spec string) (Rule, error) {
+	name, arg, hasArg := strings.Cut(strings.TrimSpace(spec), ":")
+	switch name {
+	case "original", "lower", "upper", "capitalize", "reverse", "leet":
+		if hasArg {
+			return nil, fmt.Errorf("%s takes no argument", name)
+		}
+		return funcRule(simpleRules[name]), nil
+	case "prefix":
+		return funcRule(func(w string) string { return arg + w }), nil
+	case "suffix":
+		return funcRule(func(w string) string { return w + arg }), nil
+	case "numbers":
+		return parseNumbers(arg, false)
+	case "years":
+		return parseNumbers(arg, true)
+	case "dates":
+		return parseDates(arg)
+	case "hc":
+		return parseHashcat(arg)
+	case "":
+		return nil, fmt.Errorf("empty rule")
+	}
+	return nil, fmt.Errorf("unknown rule %q", name)
+}
+
+var simpleRules = map[string]func(string) string{
+	"original": func(w string) string { return w },
+	"lower":    strings.ToLower,
+	"upper":    strings.ToUpper,
+	"capitalize": func(w string) string {
+		r, size := utf8.DecodeRuneInString(w)
+		if size == 0 {
+			return w
+		}
+		return string(unicode.ToUpper(r)) + strings.ToLower(w[size:])
+	},
+	"reverse": func(w string) string {
+		runes := []rune(w)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return string(runes)
+	},
+	"leet": leetReplacer.Replace,
+}
+
+// funcRule produces a single variant
+type funcRule func(string) string
+
+func (f funcRule) Len() int {
+	return 1
+}
+
+func (f funcRule) Apply(word string, i int) string {
+	return f(word)
+}
+
+// numbersRule appends each number in a range
+type numbersRule struct {
+	from, to int
+	width    int
+}
+
+func parseNumbers(arg string, years bool) (Rule, error) {
+	fromStr, toStr, ok := strings.Cut(arg, "-")
+	if !ok {
+		return nil, fmt.Errorf("expected FROM-TO")
+	}
+	from, err := strconv.Atoi(fromStr)
+	if err != nil || from < 0 {
+		return nil, fmt.Errorf("invalid start %q", fromStr)
+	}
+	to, err := strconv.Atoi(toStr)
+	if err != nil || to < from {
+		return nil, fmt.Errorf("invalid end %q", toStr)
+	}
+	if to-from+1 > maxVariants {
+		return nil, fmt.Errorf("range has more than %d numbers", maxVariants)
+	}
+	if years && (from < 1000 || to > 9999) {
+		return nil, fmt.Errorf("years must have four digits")
+	}
+
+	rule := &numbersRule{from: from, to: to}
+	if len(fromStr) > 1 && fromStr[0] == '0' {
+		rule.width = len(fromStr)
+	}
+	return rule, nil
+}
+
+func (r *numbersRule) Len() int {
+	return r.to - r.from + 1
+}
+
+func (r *numbersRule) Apply(word string, i int) string {
+	return fmt.Sprintf("%s%0*d", word, r.width, r.from+i)
+}
+
+// datesRule appends each day in a range
+type datesRule struct {
+	from   time.Time
+	days   int
+	layout string
+}
+
+func parseDates(arg string) (Rule, error) {
+	span, format, hasFormat := strings.Cut(arg, ":")
+	if !hasFormat {
+		format = "YYYYMMDD"
+	}
+	fromStr, toStr, ok := strings.Cut(span, "..")
+	if !ok {
+		return nil, fmt.Errorf("expected FROM..TO")
+	}
+	from, err := time.Parse(time.DateOnly, fromStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start date %q", fromStr)
+	}
+	to, err := time.Parse(time.DateOnly, toStr)
+	if err != nil || to.Before(from) {
+		return nil, fmt.Errorf("invalid end date %q", toStr)
+	}
+	layout, err := dateLayout(format)
+	if err != nil {
+		return nil, err
+	}
+
+	days := int(to.Sub(from).Hours()/24) + 1
+	if days > maxVariants {
+		return nil, fmt.Errorf("range has more than %d days", maxVariants)
+	}
+	return &datesRule{from: from, days: days, layout: layout}, nil
+}
+
+func (r *datesRule) Len() int {
+	return r.days
+}
+
+func (r *datesRule) Apply(word string, i int) string {
+	return word + r.from.AddDate(0, 0, i).Format(r.layout)
+}
+
+// dateLayout turns a format written with YYYY, YY, MM and DD into a Go time
+// layout. Other characters are kept as they are, except digits, which would
+// be read as layout elements.
+func dateLayout(format string) (string, error) {
+	var layout strings.Builder
+	for rest := format; rest != ""; {
+		switch {
+		case strings.HasPrefix(rest, "YYYY"):
+			layout.WriteString("2006")
+			rest = rest[4:]
+		case strings.HasPrefix(rest, "YY"):
+			layout.WriteString("06")
+			rest = rest[2:]
+		case strings.HasPrefix(rest, "MM"):
+			layout.WriteString("01")
+			rest = rest[2:]
+		case strings.HasPrefix(rest, "DD"):
+			layout.WriteString("02")
+			rest = rest[2:]
+		case rest[0] >= '0' && rest[0] <= '9':
+			return "", fmt.Errorf("date format %q may not contain digits", format)
+		default:
+			layout.WriteByte(rest[0])
+			rest = rest[1:]
+		}
+	}
+	return layout.String(), nil
+}
@@ -0,0 +1,123 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// variants returns every variant the set produces for word
+func variants(set *Set, word string) []string {
+	var result []string
+	for _, r := range set.Rules() {
+		for i := 0; i < r.Len(); i++ {
+			result = append(result, r.Apply(word, i))
+		}
+	}
+	return result
+}
+
+func TestNamedRules(t *testing.T) {
+	set, err := Parse([]string{
+		"original", "lower", "upper", "capitalize", "reverse", "leet",
+		"prefix:old_", "suffix:.bak",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 8, set.Len())
+	assert.Equal(t, []string{
+		"AdminTest", "admintest", "ADMINTEST", "Admintest", "tseTnimdA", "4dm1n7357",
+		"old_AdminTest", "AdminTest.bak",
+	}, variants(set, "AdminTest"))
+}
+
+func TestRangeRules(t *testing.T) {
+	set, err := Parse([]string{"numbers:8-10", "numbers:00-02", "years:2023-2024", "dates:2024-02-28..2024-03-01:DD-MM-YY"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3+3+2+3, set.Len())
+	assert.Equal(t, []string{
+		"pass8", "pass9", "pass10",
+		"pass00", "pass01", "pass02",
+		"pass2023", "pass2024",
+		"pass28-02-24", "pass29-02-24", "pass01-03-24",
+	}, variants(set, "pass"))
+
+	set, err = Parse([]string{"dates:2024-01-30..2024-02-01"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"x20240130", "x20240131", "x20240201"}, variants(set, "x"))
+}
+
+func TestHashcatRules(t *testing.T) {
+	tests := []struct {
+		rule string
+		word string
+		want string
+	}{
+		{":", "password", "password"},
+		{"c $1 $!", "pASSword", "Password1!"},
+		{"u", "pass", "PASS"},
+		{"C", "pass", "pASS"},
+		{"t", "PaSs", "pAsS"},
+		{"T1", "pass", "pAss"},
+		{"r", "abc", "cba"},
+		{"d", "ab", "abab"},
+		{"p2", "ab", "ababab"},
+		{"f", "abc", "abccba"},
+		{"{", "abc", "bca"},
+		{"}", "abc", "cab"},
+		{"^x", "abc", "xabc"},
+		{"[ ]", "abcd", "bc"},
+		{"D1", "abc", "ac"},
+		{"x12", "abcd", "bc"},
+		{"O12", "abcd", "ad"},
+		{"i1-", "abc", "a-bc"},
+		{"o0X", "abc", "Xbc"},
+		{"sa@ so0", "foobar", "f00b@r"},
+		{"@a", "banana", "bnn"},
+		{"z2", "ab", "aaab"},
+		{"Z2", "ab", "abbb"},
+		{"q", "ab", "aabb"},
+		{"'3", "abcdef", "abc"},
+		// Positions past the end leave the word alone
+		{"D9", "abc", "abc"},
+		{"'9", "abc", "abc"},
+	}
+	for _, tt := range tests {
+		set, err := Parse([]string{"hc:" + tt.rule})
+		if assert.NoError(t, err, tt.rule) {
+			assert.Equal(t, []string{tt.want}, variants(set, tt.word), tt.rule)
+		}
+	}
+}
+
+func TestHashcatRulesCapLength(t *testing.T) {
+	// Thirty doublings would be gigabytes; each stops once it would pass
+	// the limit, as in hashcat
+	set, err := Parse([]string{"hc:" + strings.Repeat("d", 30)})
+	assert.NoError(t, err)
+	got := variants(set, "abc")
+	assert.Len(t, got[0], 3*64)
+	assert.Equal(t, strings.Repeat("abc", 64), got[0])
+
+	for _, rule := range []string{"pZ", "f", "q", "zZ", "ZZ", "$x"} {
+		set, err := Parse([]string{"hc:" + rule})
+		assert.NoError(t, err)
+		word := strings.Repeat("a", maxHashcatWord)
+		assert.Equal(t, []string{word}, variants(set, word), rule)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"", "shout", "lower:x", "numbers:5", "numbers:9-1", "numbers:0-99999999",
+		"years:90-99", "dates:2024-01-01", "dates:2024-02-01..2024-01-01",
+		"dates:2024-01-01..2024-01-02:YYYY2", "hc:", "hc:Q", "hc:$", "hc:T!",
+	} {
+		_, err := Parse([]string{spec})
+		assert.Error(t, err, spec)
+	}
+
+	set, err := Parse(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, set.Len())
+}
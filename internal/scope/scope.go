@@ -0,0 +1,315 @@
+package scope
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrOutOfScope is wrapped by every scope violation
+var ErrOutOfScope = errors.New("target out of scope")
+
+// resolveTTL is how long a hostname's resolved addresses are reused
+const resolveTTL = time.Minute
+
+// Violation describes why a URL was rejected
+type Violation struct {
+	URL    string
+	Reason string
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("%v: %s (%s)", ErrOutOfScope, v.URL, v.Reason)
+}
+
+func (v *Violation) Unwrap() error {
+	return ErrOutOfScope
+}
+
+type portRange struct {
+	from, to int
+}
+
+// Rule matches a host and, optionally, a set of ports. The syntax is
+// HOST[:PORTS] where HOST is "*", a hostname glob such as "*.example.com",
+// an IP address or a CIDR, and PORTS is a comma-separated list of ports or
+// ranges such as "80,443,8000-8100". IPv6 hosts with ports use brackets:
+// "[::1]:8080".
+type Rule struct {
+	raw   string
+	glob  string
+	ip    net.IP
+	cidr  *net.IPNet
+	ports []portRange
+}
+
+func (r Rule) String() string {
+	return r.raw
+}
+
+// ParseRule parses a single scope rule
+func ParseRule(raw string) (Rule, error) {
+	rule := Rule{raw: raw}
+	host, ports := splitHostPorts(strings.TrimSpace(raw))
+	if host == "" {
+		return rule, fmt.Errorf("scope rule %q: missing host", raw)
+	}
+
+	if ports != "" {
+		for _, part := range strings.Split(ports, ",") {
+			pr, err := parsePortRange(part)
+			if err != nil {
+				return rule, fmt.Errorf("scope rule %q: %w", raw, err)
+			}
+			rule.ports = append(rule.ports, pr)
+		}
+	}
+
+	switch {
+	case strings.Contains(host, "/"):
+		_, cidr, err := net.ParseCIDR(host)
+		if err != nil {
+			return rule, fmt.Errorf("scope rule %q: invalid CIDR", raw)
+		}
+		rule.cidr = cidr
+	case net.ParseIP(host) != nil:
+		rule.ip = net.ParseIP(host)
+	default:
+		glob := strings.ToLower(host)
+		if _, err := path.Match(glob, ""); err != nil {
+			return rule, fmt.Errorf("scope rule %q: invalid hostname pattern", raw)
+		}
+		rule.glob = glob
+	}
+
+	return rule, nil
+}
+
+// splitHostPorts separates an optional port list from the host. A trailing
+// ":..." only counts as ports when the host is not a bare IPv6 address.
+func splitHostPorts(s string) (string, string) {
+	if strings.HasPrefix(s, "[") {
+		end := strings.Index(s, "]")
+		if end < 0 {
+			return s, ""
+		}
+		return s[1:end], strings.TrimPrefix(s[end+1:], ":")
+	}
+
+	i := strings.LastIndex(s, ":")
+	if i < 0 || strings.Count(s, ":") > 1 {
+		return s, ""
+	}
+	return s[:i], s[i+1:]
+}
+
+func parsePortRange(s string) (portRange, error) {
+	s = strings.TrimSpace(s)
+	if s == "*" {
+		return portRange{1, 65535}, nil
+	}
+
+	from, to, isRange := strings.Cut(s, "-")
+	lo, err := strconv.Atoi(from)
+	if err != nil || lo < 1 || lo > 65535 {
+		return portRange{}, fmt.Errorf("invalid port %q", s)
+	}
+	if !isRange {
+		return portRange{lo, lo}, nil
+	}
+
+	hi, err := strconv.Atoi(to)
+	if err != nil || hi < lo || hi > 65535 {
+		return portRange{}, fmt.Errorf("invalid port range %q", s)
+	}
+	return portRange{lo, hi}, nil
+}
+
+func (r Rule) matchesPort(port int) bool {
+	if len(r.ports) == 0 {
+		return true
+	}
+	for _, pr := range r.ports {
+		if port >= pr.from && port <= pr.to {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesHost reports whether host matches the rule. For IP and CIDR rules a
+// hostname is judged by its resolved addresses: any address is enough when
+// all is false, otherwise every address must match.
+func (r Rule) matchesHost(host string, addrs []net.IP, all bool) bool {
+	if r.glob != "" {
+		ok, _ := path.Match(r.glob, host)
+		return ok
+	}
+
+	if len(addrs) == 0 {
+		return false
+	}
+	matched := 0
+	for _, addr := range addrs {
+		if (r.ip != nil && r.ip.Equal(addr)) || (r.cidr != nil && r.cidr.Contains(addr)) {
+			matched++
+		}
+	}
+	if all {
+		return matched == len(addrs)
+	}
+	return matched > 0
+}
+
+func (r Rule) needsAddrs() bool {
+	return r.glob == ""
+}
+
+type cachedAddrs struct {
+	addrs   []net.IP
+	expires time.Time
+}
+
+// Scope decides which URLs may be requested. Deny rules always win. When
+// there are allow rules, a URL must match at least one of them; a scope with
+// no allow rules permits everything that isn't denied.
+type Scope struct {
+	allow []Rule
+	deny  []Rule
+
+	// lookup resolves hostnames for IP and CIDR rules
+	lookup func(ctx context.Context, host string) ([]net.IP, error)
+	cache  map[string]cachedAddrs
+	mu     sync.Mutex
+}
+
+// New parses the allow and deny rules into a Scope
+func New(allow, deny []string) (*Scope, error) {
+	s := &Scope{
+		lookup: lookupIP,
+		cache:  make(map[string]cachedAddrs),
+	}
+
+	var errs []error
+	for _, raw := range allow {
+		rule, err := ParseRule(raw)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		s.allow = append(s.allow, rule)
+	}
+	for _, raw := range deny {
+		rule, err := ParseRule(raw)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		s.deny = append(s.deny, rule)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return s, nil
+}
+
+// Check returns a *Violation if rawURL may not be requested
+func (s *Scope) Check(rawURL string) error {
+	if s == nil {
+		return nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return &Violation{URL: rawURL, Reason: "not an absolute URL"}
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	port := defaultPort(u)
+
+	var addrs []net.IP
+	if s.needsAddrs() {
+		addrs = s.resolve(host)
+	}
+
+	for _, rule := range s.deny {
+		if rule.matchesPort(port) && rule.matchesHost(host, addrs, false) {
+			return &Violation{URL: rawURL, Reason: fmt.Sprintf("denied by rule %q", rule.raw)}
+		}
+	}
+
+	if len(s.allow) == 0 {
+		return nil
+	}
+	for _, rule := range s.allow {
+		if rule.matchesPort(port) && rule.matchesHost(host, addrs, true) {
+			return nil
+		}
+	}
+	return &Violation{URL: rawURL, Reason: "not matched by any allow rule"}
+}
+
+func (s *Scope) needsAddrs() bool {
+	for _, rules := range [][]Rule{s.allow, s.deny} {
+		for _, rule := range rules {
+			if rule.needsAddrs() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolve returns the addresses for host, using a short-lived cache so
+// per-request checks don't hit DNS for every word
+func (s *Scope) resolve(host string) []net.IP {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}
+	}
+
+	s.mu.Lock()
+	cached, ok := s.cache[host]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.addrs
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, _ := s.lookup(ctx, host)
+
+	s.mu.Lock()
+	s.cache[host] = cachedAddrs{addrs: addrs, expires: time.Now().Add(resolveTTL)}
+	s.mu.Unlock()
+	return addrs
+}
+
+func lookupIP(ctx context.Context, host string) ([]net.IP, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, len(addrs))
+	for i, a := range addrs {
+		ips[i] = a.IP
+	}
+	return ips, nil
+}
+
+func defaultPort(u *url.URL) int {
+	if p, err := strconv.Atoi(u.Port()); err == nil {
+		return p
+	}
+	if u.Scheme == "https" {
+		return 443
+	}
+	return 80
+}
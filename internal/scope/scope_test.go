@@ -0,0 +1,83 @@
+package scope
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fakeLookup(hosts map[string][]string) func(context.Context, string) ([]net.IP, error) {
+	return func(_ context.Context, host string) ([]net.IP, error) {
+		var ips []net.IP
+		for _, a := range hosts[host] {
+			ips = append(ips, net.ParseIP(a))
+		}
+		return ips, nil
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	valid := []string{"*", "example.com", "*.example.com:443", "10.0.0.0/8", "10.0.0.1:80,443", "*:8000-9000", "[::1]:8080", "fd00::/8"}
+	for _, raw := range valid {
+		_, err := ParseRule(raw)
+		assert.NoError(t, err, raw)
+	}
+
+	invalid := []string{"", ":80", "example.com:0", "example.com:9000-8000", "10.0.0.0/99", "[a-"}
+	for _, raw := range invalid {
+		_, err := ParseRule(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestScopeCheck(t *testing.T) {
+	s, err := New(
+		[]string{"*.example.com", "example.com:80,443", "203.0.113.0/24"},
+		[]string{"admin.example.com", "10.0.0.0/8", "*:22"},
+	)
+	assert.NoError(t, err)
+	s.lookup = fakeLookup(map[string][]string{
+		"api.example.com":      {"203.0.113.10"},
+		"internal.example.com": {"10.1.2.3"},
+		"partner.test":         {"203.0.113.20"},
+		"mixed.test":           {"203.0.113.21", "198.51.100.1"},
+	})
+
+	tests := []struct {
+		url     string
+		inScope bool
+	}{
+		{"http://api.example.com/", true},
+		{"https://example.com/login", true},
+		{"http://example.com:8080/", false},     // port not allowed for apex
+		{"http://admin.example.com/", false},    // explicitly denied
+		{"http://internal.example.com/", false}, // resolves into a denied CIDR
+		{"http://api.example.com:22/", false},   // denied port
+		{"http://partner.test/", true},          // resolves into an allowed CIDR
+		{"http://mixed.test/", false},           // not every address is allowed
+		{"http://203.0.113.5/", true},
+		{"http://10.0.0.1/", false},
+		{"http://elsewhere.test/", false},
+		{"not a url", false},
+	}
+
+	for _, tt := range tests {
+		err := s.Check(tt.url)
+		if tt.inScope {
+			assert.NoError(t, err, tt.url)
+		} else {
+			assert.ErrorIs(t, err, ErrOutOfScope, tt.url)
+		}
+	}
+}
+
+func TestEmptyScopeAllowsEverything(t *testing.T) {
+	s, err := New(nil, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Check("http://anything.test/"))
+
+	var nilScope *Scope
+	assert.NoError(t, nilScope.Check("http://anything.test/"))
+}
@@ -26,11 +26,12 @@ func (s *JobStore) SaveJob(job *types.Job) error {
 
 	// Convert to a serializable format
 	type SerializableJob struct {
-		ID         string        `json:"id"`
-		Target     string        `json:"target"`
-		Status     string        `json:"status"`
-		WordlistID string        `json:"wordlistId"`
-		Type       types.JobType `json:"type"`
+		ID         string           `json:"id"`
+		Target     string           `json:"target"`
+		Status     string           `json:"status"`
+		WordlistID string           `json:"wordlistId"`
+		Type       types.JobType    `json:"type"`
+		Options    types.JobOptions `json:"options"`
 	}
 
 	serializableJobs := make(map[string]*SerializableJob)
@@ -41,6 +42,7 @@ func (s *JobStore) SaveJob(job *types.Job) error {
 			Status:     j.Status,
 			WordlistID: j.WordlistID,
 			Type:       j.Type,
+			Options:    j.Options,
 		}
 	}
 
@@ -61,7 +63,16 @@ func (s *JobStore) SaveJob(job *types.Job) error {
 	return nil
 }
 
+// Save writes every job, including findings, to the store's file
 func (s *JobStore) Save() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.save()
+}
+
+// save writes the jobs to disk. The caller must hold s.mu.
+func (s *JobStore) save() error {
 	data, err := json.Marshal(s.jobs)
 	if err != nil {
 		logging.Error("Failed to marshal jobs: %v", err)
@@ -102,7 +113,7 @@ func (s *JobStore) DeleteJob(id string) error {
 	}
 
 	delete(s.jobs, id)
-	s.Save()
+	s.save()
 	logging.Debug("Deleted job with ID: %s", id)
 	return nil
 }
@@ -0,0 +1,110 @@
+package wordlist
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"strings"
+
+	"fuzzer/internal/logging"
+	"fuzzer/types"
+)
+
+// BuiltinPrefix starts the IDs of the wordlists shipped in the binary.
+// Their IDs never change, so scripts can rely on them.
+const BuiltinPrefix = "builtin:"
+
+//go:embed builtin/*.txt
+var builtinFS embed.FS
+
+// builtins are the wordlists shipped in the binary
+var builtins = []struct {
+	id, name, file string
+	description    string
+	tags           []string
+}{
+	{
+		BuiltinPrefix + "directories", "Common directories", "builtin/directories.txt",
+		"Directory names found on most web servers and frameworks",
+		[]string{"directory", "web"},
+	},
+	{
+		BuiltinPrefix + "files", "Common files", "builtin/files.txt",
+		"Configuration, metadata and debug files that are often left exposed",
+		[]string{"files", "sensitive", "web"},
+	},
+	{
+		BuiltinPrefix + "subdomains", "Common subdomains", "builtin/subdomains.txt",
+		"Frequent subdomain and virtual host names",
+		[]string{"dns", "subdomain"},
+	},
+	{
+		BuiltinPrefix + "parameters", "Common parameters", "builtin/parameters.txt",
+		"Query and form parameter names",
+		[]string{"parameters", "web"},
+	},
+	{
+		BuiltinPrefix + "backups", "Backup files", "builtin/backups.txt",
+		"Archives, database dumps and editor backups of common files",
+		[]string{"backup", "files", "sensitive"},
+	},
+}
+
+// IsBuiltin reports whether id names a wordlist shipped in the binary
+func IsBuiltin(id string) bool {
+	return strings.HasPrefix(id, BuiltinPrefix)
+}
+
+// RegisterBuiltins makes the wordlists shipped in the binary available
+// alongside the stored ones. They are read-only: they can be used, derived
+// from and downloaded, but not renamed, changed or deleted.
+func (m *Manager) RegisterBuiltins() error {
+	for _, b := range builtins {
+		s, err := builtinStats(b.file)
+		if err != nil {
+			return fmt.Errorf("failed to read builtin wordlist %s: %w", b.id, err)
+		}
+
+		meta := metadata{ID: b.id, Name: b.name}
+		meta.WordlistInfo = types.WordlistInfo{Description: b.description, Tags: b.tags, Source: "builtin"}
+		meta.setStats(s)
+		wordlist := meta.wordlist()
+		wordlist.Builtin = true
+
+		m.mu.Lock()
+		m.lists[b.id] = wordlist
+		// Lists derived from it may have been loaded before it existed
+		m.refreshDerived(b.id)
+		m.mu.Unlock()
+		logging.Info("Registered builtin wordlist: ID=%s Lines=%d", b.id, wordlist.Lines)
+	}
+	return nil
+}
+
+func builtinStats(file string) (*stats, error) {
+	f, err := builtinFS.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s := newStats()
+	if _, err := io.Copy(s, f); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// openBuiltin returns an Iterator over a builtin wordlist
+func openBuiltin(id string) (Iterator, error) {
+	for _, b := range builtins {
+		if b.id == id {
+			f, err := builtinFS.Open(b.file)
+			if err != nil {
+				return nil, err
+			}
+			return newReaderIterator(f), nil
+		}
+	}
+	return nil, ErrNotFound
+}
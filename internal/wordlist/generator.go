@@ -0,0 +1,313 @@
+package wordlist
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"fuzzer/internal/rules"
+	"fuzzer/types"
+)
+
+// GeneratorPrefix starts the IDs of generated wordlists. A generator ID can
+// be used anywhere a wordlist ID is accepted:
+//
+//	gen:range:FROM-TO[:STEP]          numbers; a leading zero in FROM pads
+//	                                  every number to its width
+//	gen:charset:CHARS:MIN-MAX         every string of MIN to MAX characters
+//	                                  from CHARS, or from one of lower,
+//	                                  upper, digits, alpha, alnum or hex
+//	gen:dates:FROM..TO[:FORMAT]       days from FROM to TO (YYYY-MM-DD),
+//	                                  formatted with YYYY, YY, MM and DD
+//	gen:uuid:COUNT[:START]            COUNT sequential UUIDs from START,
+//	                                  which defaults to the nil UUID
+const GeneratorPrefix = "gen:"
+
+// maxKeyspace caps the number of words a generator may produce
+const maxKeyspace = 1 << 50
+
+// Keyspace returns the number of words a list of lines yields when each
+// gets variants rules applied. Past maxKeyspace it returns maxKeyspace and
+// an error rather than overflowing.
+func Keyspace(lines, variants int) (int, error) {
+	if variants > 0 && lines > maxKeyspace/variants {
+		return maxKeyspace, fmt.Errorf("%w: more than %d words", ErrInvalidWordlist, maxKeyspace)
+	}
+	return lines * variants, nil
+}
+
+// charsets are the named character sets of the charset generator
+var charsets = map[string]string{
+	"lower":  "abcdefghijklmnopqrstuvwxyz",
+	"upper":  "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"digits": "0123456789",
+	"alpha":  "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"alnum":  "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789",
+	"hex":    "0123456789abcdef",
+}
+
+// IsGenerator reports whether id names a generator rather than a stored list
+func IsGenerator(id string) bool {
+	return strings.HasPrefix(id, GeneratorPrefix)
+}
+
+// ParseGenerator parses a generator ID
+func ParseGenerator(id string) (Generator, error) {
+	spec, ok := strings.CutPrefix(id, GeneratorPrefix)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is not a generator", ErrInvalidWordlist, id)
+	}
+	kind, arg, _ := strings.Cut(spec, ":")
+
+	var gen Generator
+	var err error
+	switch kind {
+	case "range":
+		gen, err = parseRange(arg)
+	case "charset":
+		gen, err = parseCharset(arg)
+	case "dates":
+		gen, err = parseDates(arg)
+	case "uuid":
+		gen, err = parseUUIDs(arg)
+	default:
+		err = fmt.Errorf("unknown generator %q", kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: generator %q: %v", ErrInvalidWordlist, id, err)
+	}
+	return gen, nil
+}
+
+// generatedWordlist describes a generator the way a stored list is described
+func generatedWordlist(id string) *types.Wordlist {
+	gen, err := ParseGenerator(id)
+	if err != nil {
+		return nil
+	}
+	return &types.Wordlist{ID: id, Name: id, Lines: gen.Len()}
+}
+
+// generatorIterator yields the words of a generator in order
+type generatorIterator struct {
+	gen  Generator
+	pos  int
+	word string
+}
+
+// NewGeneratorIterator returns an Iterator over the words of gen
+func NewGeneratorIterator(gen Generator) Iterator {
+	return &generatorIterator{gen: gen}
+}
+
+func (it *generatorIterator) Next() bool {
+	if it.pos >= it.gen.Len() {
+		return false
+	}
+	it.word = it.gen.Word(it.pos)
+	it.pos++
+	return true
+}
+
+func (it *generatorIterator) Word() string {
+	return it.word
+}
+
+func (it *generatorIterator) Err() error {
+	return nil
+}
+
+func (it *generatorIterator) Close() error {
+	return nil
+}
+
+// rangeGenerator produces numbers from, from+step, ... up to to
+type rangeGenerator struct {
+	from, step, count int
+	width             int
+}
+
+func parseRange(arg string) (Generator, error) {
+	span, stepStr, hasStep := strings.Cut(arg, ":")
+	fromStr, toStr, ok := strings.Cut(span, "-")
+	if !ok {
+		return nil, fmt.Errorf("expected FROM-TO")
+	}
+	from, err := strconv.Atoi(fromStr)
+	if err != nil || from < 0 {
+		return nil, fmt.Errorf("invalid start %q", fromStr)
+	}
+	to, err := strconv.Atoi(toStr)
+	if err != nil || to < from {
+		return nil, fmt.Errorf("invalid end %q", toStr)
+	}
+	step := 1
+	if hasStep {
+		step, err = strconv.Atoi(stepStr)
+		if err != nil || step < 1 {
+			return nil, fmt.Errorf("invalid step %q", stepStr)
+		}
+	}
+
+	// Checked before adding one, which overflows for the widest ranges
+	if (to-from)/step >= maxKeyspace {
+		return nil, fmt.Errorf("more than %d words", maxKeyspace)
+	}
+	gen := &rangeGenerator{from: from, step: step, count: (to-from)/step + 1}
+	if len(fromStr) > 1 && fromStr[0] == '0' {
+		gen.width = len(fromStr)
+	}
+	return gen, nil
+}
+
+func (g *rangeGenerator) Len() int {
+	return g.count
+}
+
+func (g *rangeGenerator) Word(i int) string {
+	return fmt.Sprintf("%0*d", g.width, g.from+i*g.step)
+}
+
+// charsetGenerator produces every string of minLen to maxLen characters,
+// shortest first
+type charsetGenerator struct {
+	chars []rune
+	// sizes[n] is the number of strings of length minLen+n
+	sizes  []int
+	minLen int
+	count  int
+}
+
+func parseCharset(arg string) (Generator, error) {
+	// The lengths come last so the charset itself may contain colons
+	sep := strings.LastIndex(arg, ":")
+	if sep < 0 {
+		return nil, fmt.Errorf("expected CHARS:MIN-MAX")
+	}
+	chars, lengths := arg[:sep], arg[sep+1:]
+	if named, ok := charsets[chars]; ok {
+		chars = named
+	}
+	if chars == "" {
+		return nil, fmt.Errorf("empty charset")
+	}
+
+	minStr, maxStr, ok := strings.Cut(lengths, "-")
+	if !ok {
+		minStr, maxStr = lengths, lengths
+	}
+	minLen, err := strconv.Atoi(minStr)
+	if err != nil || minLen < 1 {
+		return nil, fmt.Errorf("invalid minimum length %q", minStr)
+	}
+	maxLen, err := strconv.Atoi(maxStr)
+	if err != nil || maxLen < minLen {
+		return nil, fmt.Errorf("invalid maximum length %q", maxStr)
+	}
+
+	gen := &charsetGenerator{chars: []rune(chars), minLen: minLen}
+	size := 1
+	for n := 1; n <= maxLen; n++ {
+		if size > maxKeyspace/len(gen.chars) {
+			return nil, fmt.Errorf("more than %d words", maxKeyspace)
+		}
+		size *= len(gen.chars)
+		if n >= minLen {
+			gen.sizes = append(gen.sizes, size)
+			gen.count += size
+		}
+	}
+	if gen.count > maxKeyspace {
+		return nil, fmt.Errorf("more than %d words", maxKeyspace)
+	}
+	return gen, nil
+}
+
+func (g *charsetGenerator) Len() int {
+	return g.count
+}
+
+func (g *charsetGenerator) Word(i int) string {
+	length := g.minLen
+	for _, size := range g.sizes {
+		if i < size {
+			break
+		}
+		i -= size
+		length++
+	}
+
+	// i is now a number in base len(chars), with the last character
+	// varying fastest
+	word := make([]rune, length)
+	for pos := length - 1; pos >= 0; pos-- {
+		word[pos] = g.chars[i%len(g.chars)]
+		i /= len(g.chars)
+	}
+	return string(word)
+}
+
+// ruleGenerator produces the variants of a rule applied to the empty word,
+// which is how date ranges share the dates rule
+type ruleGenerator struct {
+	rule rules.Rule
+}
+
+func parseDates(arg string) (Generator, error) {
+	set, err := rules.Parse([]string{"dates:" + arg})
+	if err != nil {
+		return nil, err
+	}
+	return &ruleGenerator{rule: set.Rules()[0]}, nil
+}
+
+func (g *ruleGenerator) Len() int {
+	return g.rule.Len()
+}
+
+func (g *ruleGenerator) Word(i int) string {
+	return g.rule.Apply("", i)
+}
+
+// uuidGenerator produces count UUIDs counting up from start
+type uuidGenerator struct {
+	start *big.Int
+	count int
+}
+
+// maxUUID is the largest 128-bit value
+var maxUUID = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+
+func parseUUIDs(arg string) (Generator, error) {
+	countStr, startStr, _ := strings.Cut(arg, ":")
+	count, err := strconv.Atoi(countStr)
+	if err != nil || count < 1 || count > maxKeyspace {
+		return nil, fmt.Errorf("invalid count %q", countStr)
+	}
+
+	start := new(big.Int)
+	if startStr != "" {
+		hex := strings.ReplaceAll(startStr, "-", "")
+		if len(hex) != 32 {
+			return nil, fmt.Errorf("invalid start UUID %q", startStr)
+		}
+		if _, ok := start.SetString(hex, 16); !ok {
+			return nil, fmt.Errorf("invalid start UUID %q", startStr)
+		}
+	}
+	last := new(big.Int).Add(start, big.NewInt(int64(count-1)))
+	if last.Cmp(maxUUID) > 0 {
+		return nil, fmt.Errorf("range passes the largest UUID")
+	}
+	return &uuidGenerator{start: start, count: count}, nil
+}
+
+func (g *uuidGenerator) Len() int {
+	return g.count
+}
+
+func (g *uuidGenerator) Word(i int) string {
+	hex := fmt.Sprintf("%032x", new(big.Int).Add(g.start, big.NewInt(int64(i))))
+	return hex[0:8] + "-" + hex[8:12] + "-" + hex[12:16] + "-" + hex[16:20] + "-" + hex[20:32]
+}
@@ -0,0 +1,84 @@
+package wordlist
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// generate returns every word of a generator ID
+func generate(t *testing.T, id string) []string {
+	gen, err := ParseGenerator(id)
+	if !assert.NoError(t, err, id) {
+		return nil
+	}
+	var words []string
+	it := NewGeneratorIterator(gen)
+	for it.Next() {
+		words = append(words, it.Word())
+	}
+	assert.Len(t, words, gen.Len(), id)
+	return words
+}
+
+func TestGenerators(t *testing.T) {
+	assert.Equal(t, []string{"1", "2", "3"}, generate(t, "gen:range:1-3"))
+	assert.Equal(t, []string{"0", "5", "10"}, generate(t, "gen:range:0-12:5"))
+	assert.Equal(t, []string{"0098", "0099", "0100"}, generate(t, "gen:range:0098-0100"))
+
+	assert.Equal(t, []string{"a", "b", "aa", "ab", "ba", "bb"}, generate(t, "gen:charset:ab:1-2"))
+	assert.Equal(t, []string{"00", "01", "10", "11"}, generate(t, "gen:charset:01:2"))
+	assert.Equal(t, []string{"a", ":", "b"}, generate(t, "gen:charset:a:b:1"))
+	assert.Len(t, generate(t, "gen:charset:digits:3"), 1000)
+	assert.Equal(t, "ff", generate(t, "gen:charset:hex:1-2")[16+255])
+
+	assert.Equal(t, []string{"2024-02-28", "2024-02-29", "2024-03-01"}, generate(t, "gen:dates:2024-02-28..2024-03-01:YYYY-MM-DD"))
+
+	assert.Equal(t, []string{
+		"00000000-0000-0000-0000-000000000000",
+		"00000000-0000-0000-0000-000000000001",
+	}, generate(t, "gen:uuid:2"))
+	assert.Equal(t, []string{
+		"123e4567-e89b-12d3-a456-4266141740ff",
+		"123e4567-e89b-12d3-a456-426614174100",
+	}, generate(t, "gen:uuid:2:123e4567-e89b-12d3-a456-4266141740ff"))
+}
+
+func TestGeneratorErrors(t *testing.T) {
+	for _, id := range []string{
+		"gen:", "gen:fibonacci:10", "gen:range:5", "gen:range:9-1", "gen:range:1-9:0",
+		"gen:range:0-9223372036854775807", "gen:range:0-1125899906842624",
+		"gen:charset::1-2", "gen:charset:ab", "gen:charset:ab:3-1", "gen:charset:alnum:1-12",
+		"gen:dates:2024-01-01", "gen:uuid:0", "gen:uuid:2:nope",
+		"gen:uuid:2:ffffffff-ffff-ffff-ffff-ffffffffffff",
+	} {
+		_, err := ParseGenerator(id)
+		assert.ErrorIs(t, err, ErrInvalidWordlist, id)
+	}
+}
+
+func TestManagerGenerators(t *testing.T) {
+	manager, err := NewManager(t.TempDir())
+	assert.NoError(t, err)
+
+	// Generators work wherever a wordlist ID does, without being listed
+	list := manager.Get("gen:range:1-50000")
+	assert.NotNil(t, list)
+	assert.Equal(t, 50000, list.Lines)
+	assert.Empty(t, manager.List())
+	assert.Nil(t, manager.Get("gen:range:oops"))
+
+	id, err := manager.Derive("invoices", "gen:range:1-3", []string{"prefix:INV-"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, manager.Get(id).Lines)
+	assert.Equal(t, "INV-1 INV-2 INV-3", strings.Join(words(t, manager, id), " "))
+
+	_, err = manager.Derive("bad", "gen:range:oops", []string{"upper"})
+	assert.ErrorIs(t, err, ErrInvalidWordlist)
+
+	// Rules can't multiply a generator past the keyspace limit
+	_, err = manager.Derive("huge", "gen:range:0-999999999999999", []string{"numbers:0-9999999"})
+	assert.ErrorIs(t, err, ErrInvalidWordlist)
+	assert.Len(t, manager.List(), 1)
+}
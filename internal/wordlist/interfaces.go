@@ -1,10 +1,42 @@
 package wordlist
 
-import "fuzzer/types"
+import (
+	"io"
+
+	"fuzzer/types"
+)
 
 type WordlistStorer interface {
 	Get(id string) *types.Wordlist
 	GetByName(name string) *types.Wordlist
-	Add(name string, words []string) string
+	Add(name string, r io.Reader, info types.WordlistInfo) (string, error)
+	Derive(name, baseID string, rules []string) (string, error)
 	List() []*types.Wordlist
+	Delete(id string) error
+	Rename(id, name string) error
+	Describe(id string, info types.WordlistInfo) error
+	Replace(id string, r io.Reader) error
+	Append(id string, words []string) error
+	Iterate(id string) (Iterator, error)
+	IterateRanked(id string, jobType types.JobType) (Iterator, error)
+	Generated(id string) bool
+	Learn(jobType types.JobType, word string) error
+}
+
+// Iterator reads the words of a wordlist one at a time, in the style of
+// bufio.Scanner. Next advances to the next word and returns false at the end
+// of the list or on an error, which Err then reports. Close must be called
+// once the caller is done.
+type Iterator interface {
+	Next() bool
+	Word() string
+	Err() error
+	Close() error
+}
+
+// Generator produces a fixed number of words by index, so generated lists
+// have an exact size without being stored
+type Generator interface {
+	Len() int
+	Word(i int) string
 }
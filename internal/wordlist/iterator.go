@@ -0,0 +1,161 @@
+package wordlist
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// maxWordLength is the longest line a wordlist may contain
+const maxWordLength = 1024 * 1024
+
+// fileIterator reads words from a wordlist file line by line
+type fileIterator struct {
+	file    io.ReadCloser
+	scanner *bufio.Scanner
+}
+
+func newFileIterator(path string) (*fileIterator, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return newReaderIterator(file), nil
+}
+
+// newReaderIterator reads words line by line from file, closing it when the
+// iterator is closed
+func newReaderIterator(file io.ReadCloser) *fileIterator {
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxWordLength)
+	return &fileIterator{file: file, scanner: scanner}
+}
+
+func (it *fileIterator) Next() bool {
+	return it.scanner.Scan()
+}
+
+func (it *fileIterator) Word() string {
+	return strings.TrimSuffix(it.scanner.Text(), "\r")
+}
+
+func (it *fileIterator) Err() error {
+	return it.scanner.Err()
+}
+
+func (it *fileIterator) Close() error {
+	return it.file.Close()
+}
+
+// sliceIterator iterates over words already in memory
+type sliceIterator struct {
+	words []string
+	pos   int
+}
+
+// NewSliceIterator returns an Iterator over words
+func NewSliceIterator(words []string) Iterator {
+	return &sliceIterator{words: words}
+}
+
+func (it *sliceIterator) Next() bool {
+	if it.pos >= len(it.words) {
+		return false
+	}
+	it.pos++
+	return true
+}
+
+func (it *sliceIterator) Word() string {
+	return it.words[it.pos-1]
+}
+
+func (it *sliceIterator) Err() error {
+	return nil
+}
+
+func (it *sliceIterator) Close() error {
+	return nil
+}
+
+// stats is an io.Writer that works out a wordlist's line count, size and
+// checksum from the bytes written to it, so files are never read into memory
+type stats struct {
+	lines int
+	size  int64
+	// chars counts the bytes of the words, leaving out line endings
+	chars   int64
+	last    byte
+	current int
+	longest int
+	hash    hash.Hash
+}
+
+func newStats() *stats {
+	return &stats{hash: sha256.New()}
+}
+
+func (s *stats) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	for _, b := range p {
+		if b == '\n' {
+			s.lines++
+			s.current = 0
+			continue
+		}
+		if b != '\r' {
+			s.chars++
+		}
+		s.current++
+		if s.current > s.longest {
+			s.longest = s.current
+		}
+	}
+	s.size += int64(len(p))
+	s.last = p[len(p)-1]
+	return s.hash.Write(p)
+}
+
+// lineCount returns the number of words an iterator yields for the file,
+// counting a final line without a trailing newline
+func (s *stats) lineCount() int {
+	if s.size > 0 && s.last != '\n' {
+		return s.lines + 1
+	}
+	return s.lines
+}
+
+// avgWordLength returns the mean word length in bytes, to two decimal places
+func (s *stats) avgWordLength() float64 {
+	lines := s.lineCount()
+	if lines == 0 {
+		return 0
+	}
+	return math.Round(float64(s.chars)/float64(lines)*100) / 100
+}
+
+func (s *stats) checksum() string {
+	return hex.EncodeToString(s.hash.Sum(nil))
+}
+
+// fileStats reads the file at path through stats
+func fileStats(path string) (*stats, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	s := newStats()
+	if _, err := io.Copy(s, file); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,274 @@
+package wordlist
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"fuzzer/internal/logging"
+	"fuzzer/types"
+)
+
+// LearnedPrefix starts the IDs of the learned wordlists, one per job type:
+// learned:directory holds every word that produced a directory finding,
+// most productive first. Like generators, they can be used anywhere a
+// wordlist ID is accepted but can't be changed.
+const LearnedPrefix = "learned:"
+
+// learnedFile holds the learned hit counts. It doesn't end in metaExt, so
+// scan doesn't mistake it for a wordlist.
+const learnedFile = "learned.hits"
+
+// learnedFlushInterval is how often changed hit counts are saved. Counts
+// learned since the last save are lost if the process dies, which only
+// costs ranking a little.
+const learnedFlushInterval = 30 * time.Second
+
+// IsLearned reports whether id names a learned wordlist
+func IsLearned(id string) bool {
+	return strings.HasPrefix(id, LearnedPrefix)
+}
+
+// LearnedID returns the ID of the learned wordlist for a job type
+func LearnedID(jobType types.JobType) string {
+	return LearnedPrefix + string(jobType)
+}
+
+// Learn records that word produced a finding in a job of the given type.
+// Counts are kept in memory and saved every learnedFlushInterval and on
+// Close, so findings don't wait on the disk.
+func (m *Manager) Learn(jobType types.JobType, word string) error {
+	if jobType == "" || word == "" || strings.ContainsAny(word, "\r\n") {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hits := m.learned[jobType]
+	if hits == nil {
+		hits = make(map[string]int)
+		m.learned[jobType] = hits
+	}
+	hits[word]++
+	m.learnedDirty = true
+	return nil
+}
+
+// FlushLearned saves the hit counts if they changed since they were last
+// saved. The file is written without holding m.mu.
+func (m *Manager) FlushLearned() error {
+	m.flushMu.Lock()
+	defer m.flushMu.Unlock()
+
+	m.mu.Lock()
+	if !m.learnedDirty {
+		m.mu.Unlock()
+		return nil
+	}
+	data, err := json.Marshal(m.learned)
+	m.learnedDirty = false
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	err = writeFileAtomic(filepath.Join(m.baseDir, learnedFile), func(f *os.File) error {
+		_, err := f.Write(data)
+		return err
+	})
+	if err != nil {
+		m.mu.Lock()
+		m.learnedDirty = true
+		m.mu.Unlock()
+		logging.Error("Failed to save learned words: %v", err)
+		return fmt.Errorf("failed to save learned words: %w", err)
+	}
+	return nil
+}
+
+// flushLearned saves the hit counts every learnedFlushInterval until Close
+func (m *Manager) flushLearned() {
+	defer close(m.flushed)
+	ticker := time.NewTicker(learnedFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.FlushLearned()
+		case <-m.closing:
+			return
+		}
+	}
+}
+
+// Close stops the periodic saving of hit counts and saves them one last
+// time
+func (m *Manager) Close() error {
+	m.closeOnce.Do(func() { close(m.closing) })
+	<-m.flushed
+	return m.FlushLearned()
+}
+
+// loadLearned reads the hit counts saved by earlier runs
+func (m *Manager) loadLearned() error {
+	data, err := os.ReadFile(filepath.Join(m.baseDir, learnedFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	learned := make(map[types.JobType]map[string]int)
+	if err := json.Unmarshal(data, &learned); err != nil {
+		return fmt.Errorf("invalid learned words: %w", err)
+	}
+	for jobType, hits := range learned {
+		if len(hits) > 0 {
+			m.learned[jobType] = hits
+		}
+	}
+	return nil
+}
+
+// learnedWords returns the words learned for the type of a learned list,
+// most hits first, then alphabetically. The caller holds m.mu.
+func (m *Manager) learnedWords(id string) []string {
+	hits := m.learned[types.JobType(strings.TrimPrefix(id, LearnedPrefix))]
+	words := make([]string, 0, len(hits))
+	for w := range hits {
+		words = append(words, w)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if hits[words[i]] != hits[words[j]] {
+			return hits[words[i]] > hits[words[j]]
+		}
+		return words[i] < words[j]
+	})
+	return words
+}
+
+// learnedWordlist describes a learned list. The caller holds m.mu.
+func (m *Manager) learnedWordlist(id string) *types.Wordlist {
+	jobType := strings.TrimPrefix(id, LearnedPrefix)
+	if jobType == "" {
+		return nil
+	}
+	return &types.Wordlist{
+		ID:   id,
+		Name: "Learned " + jobType + " words",
+		WordlistInfo: types.WordlistInfo{
+			Description: "Words that produced " + jobType + " findings, most productive first",
+			Tags:        []string{"learned"},
+			Source:      "findings",
+		},
+		Lines: len(m.learned[types.JobType(jobType)]),
+	}
+}
+
+// IterateRanked iterates over a wordlist with the words that produced the
+// most findings in jobs of the given type moved to the front, so long runs
+// surface findings early. The other words keep their order, and every word
+// is still read exactly as many times as it appears in the list.
+func (m *Manager) IterateRanked(id string, jobType types.JobType) (Iterator, error) {
+	// Ranking reads the list twice, which generated lists are too long for
+	if m.Generated(id) {
+		return nil, fmt.Errorf("%w: generated lists can't be ranked", ErrInvalidWordlist)
+	}
+	m.mu.RLock()
+	ranked := m.learnedWords(LearnedID(jobType))
+	m.mu.RUnlock()
+	if len(ranked) == 0 || IsLearned(id) {
+		return m.Iterate(id)
+	}
+
+	// A first pass finds how often each learned word appears in the list,
+	// holding no more than the learned words in memory
+	counts := make(map[string]int, len(ranked))
+	for _, w := range ranked {
+		counts[w] = 0
+	}
+	it, err := m.Iterate(id)
+	if err != nil {
+		return nil, err
+	}
+	for it.Next() {
+		if n, learned := counts[it.Word()]; learned {
+			counts[it.Word()] = n + 1
+		}
+	}
+	err = it.Err()
+	it.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var front []string
+	for _, w := range ranked {
+		for i := 0; i < counts[w]; i++ {
+			front = append(front, w)
+		}
+	}
+	rest, err := m.Iterate(id)
+	if err != nil {
+		return nil, err
+	}
+	return &rankedIterator{front: NewSliceIterator(front), rest: rest, skip: counts, inFront: true}, nil
+}
+
+// Generated reports whether a list is a generator or derived from one, and
+// so may be too long to read more than once
+func (m *Manager) Generated(id string) bool {
+	for !IsGenerator(id) {
+		list := m.Get(id)
+		if list == nil || list.Base == "" {
+			return false
+		}
+		id = list.Base
+	}
+	return true
+}
+
+// rankedIterator yields the front words, then the rest of the list without
+// the words already yielded
+type rankedIterator struct {
+	front   Iterator
+	rest    Iterator
+	skip    map[string]int
+	inFront bool
+}
+
+func (it *rankedIterator) Next() bool {
+	if it.inFront {
+		if it.front.Next() {
+			return true
+		}
+		it.inFront = false
+	}
+	for it.rest.Next() {
+		if it.skip[it.rest.Word()] == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (it *rankedIterator) Word() string {
+	if it.inFront {
+		return it.front.Word()
+	}
+	return it.rest.Word()
+}
+
+func (it *rankedIterator) Err() error {
+	return it.rest.Err()
+}
+
+func (it *rankedIterator) Close() error {
+	return it.rest.Close()
+}
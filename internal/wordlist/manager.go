@@ -1,73 +1,804 @@
 package wordlist
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"fuzzer/internal/logging"
+	"fuzzer/internal/rules"
 	"fuzzer/types"
 	"fuzzer/utils"
 )
 
+const (
+	// wordsExt holds the words of a list, one per line. Plain text files
+	// dropped into the directory are picked up as wordlists.
+	wordsExt = ".txt"
+	// metaExt holds the metadata of a list saved by the manager
+	metaExt = ".json"
+)
+
+// metadata is stored next to each wordlist as <id>.json
+type metadata struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name"`
+	Created time.Time `json:"created"`
+	types.WordlistInfo
+	Lines         int      `json:"lines,omitempty"`
+	AvgWordLength float64  `json:"avgWordLength,omitempty"`
+	Size          int64    `json:"size,omitempty"`
+	Checksum      string   `json:"checksum,omitempty"`
+	Base          string   `json:"base,omitempty"`
+	Rules         []string `json:"rules,omitempty"`
+}
+
+func (meta *metadata) setStats(s *stats) {
+	meta.Lines = s.lineCount()
+	meta.AvgWordLength = s.avgWordLength()
+	meta.Size = s.size
+	meta.Checksum = s.checksum()
+}
+
+// keepStats copies the stats the manager already holds for a list, for
+// updates that don't touch its words
+func (meta *metadata) keepStats(wl *types.Wordlist) {
+	meta.Lines = wl.Lines
+	meta.AvgWordLength = wl.AvgWordLength
+	meta.Size = wl.Size
+	meta.Checksum = wl.Checksum
+}
+
+func (meta metadata) wordlist() *types.Wordlist {
+	return &types.Wordlist{
+		ID:            meta.ID,
+		Name:          meta.Name,
+		WordlistInfo:  meta.WordlistInfo,
+		Lines:         meta.Lines,
+		AvgWordLength: meta.AvgWordLength,
+		Size:          meta.Size,
+		Checksum:      meta.Checksum,
+		Base:          meta.Base,
+		Rules:         meta.Rules,
+	}
+}
+
 type Manager struct {
 	baseDir string
 	lists   map[string]*types.Wordlist
+	// learned counts the findings each word produced, by job type, and
+	// learnedDirty is set when they changed since they were saved
+	learned      map[types.JobType]map[string]int
+	learnedDirty bool
+	mu           sync.RWMutex
+	// writeMu serialises Replace and Append, which write the new words
+	// without holding mu so reads aren't held up by a slow upload
+	writeMu sync.Mutex
+	// scannedAt is the base directory's modification time when it was
+	// last scanned
+	scannedAt time.Time
+	// flushMu serialises saving the hit counts
+	flushMu   sync.Mutex
+	closing   chan struct{}
+	closeOnce sync.Once
+	flushed   chan struct{}
 }
 
+var (
+	// ErrNotFound is returned when a wordlist ID is unknown
+	ErrNotFound = errors.New("wordlist not found")
+	// ErrInUse is returned when deleting a list other lists are derived from
+	ErrInUse = errors.New("wordlist is in use")
+	// ErrReadOnly is returned when changing or deleting a builtin list
+	ErrReadOnly = errors.New("wordlist is read-only")
+)
+
+// Get returns the wordlist with the given ID. Stored wordlists are never
+// modified in place: Replace, Append and Rename swap in a new value, so the
+// result is shared rather than copied and must not be modified.
 func (m *Manager) Get(id string) *types.Wordlist {
+	if IsGenerator(id) {
+		return generatedWordlist(id)
+	}
+	if IsLearned(id) {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		return m.learnedWordlist(id)
+	}
+
+	m.mu.RLock()
 	wordlist, exists := m.lists[id]
+	m.mu.RUnlock()
+
+	// The list may have been dropped into the directory since the last
+	// scan. Files are only added by changing the directory, so unknown IDs
+	// don't cost a scan each.
+	if !exists && m.dirChanged() {
+		m.scan()
+		m.mu.RLock()
+		wordlist, exists = m.lists[id]
+		m.mu.RUnlock()
+	}
 	if !exists {
 		logging.Debug("Wordlist not found with ID: %s", id)
 		return nil
 	}
 
 	logging.Debug("Retrieved wordlist: ID=%s Name=%s", wordlist.ID, wordlist.Name)
-	return &types.Wordlist{
-		ID:    wordlist.ID,
-		Name:  wordlist.Name,
-		Words: wordlist.Words,
-	}
+	return wordlist
 }
 
 func (m *Manager) GetByName(name string) *types.Wordlist {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	for _, v := range m.lists {
 		if v.Name == name {
 			logging.Debug("Retrieved wordlist by name: Name=%s ID=%s", name, v.ID)
-			return &types.Wordlist{
-				ID:    v.ID,
-				Name:  v.Name,
-				Words: v.Words,
-			}
+			return v
 		}
 	}
 	logging.Debug("No wordlist found with name: %s", name)
 	return nil
 }
 
-func (m *Manager) Add(name string, words []string) string {
+// Add streams a new wordlist from r to the base directory and returns its
+// ID. The info is saved with it, so the list is never seen without it.
+func (m *Manager) Add(name string, r io.Reader, info types.WordlistInfo) (string, error) {
+	id := utils.GenerateID()
+
+	s, err := writeWordlist(m.wordsPath(id), r)
+	if err != nil {
+		logging.Error("Failed to save wordlist %s: %v", name, err)
+		return "", fmt.Errorf("failed to save wordlist: %w", err)
+	}
+
+	info.Tags = NormalizeTags(info.Tags)
+	meta := metadata{ID: id, Name: name, Created: time.Now(), WordlistInfo: info}
+	meta.setStats(s)
+	if err := m.saveMetadata(meta); err != nil {
+		os.Remove(m.wordsPath(id))
+		logging.Error("Failed to save wordlist metadata %s: %v", name, err)
+		return "", fmt.Errorf("failed to save wordlist metadata: %w", err)
+	}
+
+	m.mu.Lock()
+	m.lists[id] = meta.wordlist()
+	m.mu.Unlock()
+
+	logging.Info("Added new wordlist: ID=%s Name=%s Lines=%d Size=%d", id, name, meta.Lines, meta.Size)
+	return id, nil
+}
+
+// Derive saves a named wordlist whose words are generated from the base
+// list by rules. Nothing but the metadata is stored; the variants are
+// produced while the list is iterated.
+func (m *Manager) Derive(name, baseID string, specs []string) (string, error) {
+	set, err := rules.Parse(specs)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidWordlist, err)
+	}
+	if len(specs) == 0 {
+		return "", fmt.Errorf("%w: a derived wordlist needs at least one rule", ErrInvalidWordlist)
+	}
+
+	if IsGenerator(baseID) {
+		if _, err := ParseGenerator(baseID); err != nil {
+			return "", err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.lists[baseID]; !exists && !IsGenerator(baseID) && !IsLearned(baseID) {
+		return "", fmt.Errorf("base %w", ErrNotFound)
+	}
+
+	lines, err := Keyspace(m.linesOf(baseID), set.Len())
+	if err != nil {
+		return "", err
+	}
+
 	id := utils.GenerateID()
-	m.lists[id] = &types.Wordlist{
-		ID:    id,
-		Name:  name,
-		Words: words,
+	meta := metadata{ID: id, Name: name, Created: time.Now(), Base: baseID, Rules: specs}
+	if err := m.saveMetadata(meta); err != nil {
+		logging.Error("Failed to save wordlist metadata %s: %v", name, err)
+		return "", fmt.Errorf("failed to save wordlist metadata: %w", err)
+	}
+
+	meta.Lines = lines
+	m.lists[id] = meta.wordlist()
+	logging.Info("Derived wordlist: ID=%s Name=%s Base=%s Rules=%d Lines=%d", id, name, baseID, len(specs), meta.Lines)
+	return id, nil
+}
+
+// Delete removes a wordlist and its files. Iterators already open keep
+// reading the deleted file until they are closed.
+func (m *Manager) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wordlist, exists := m.lists[id]
+	if !exists {
+		return ErrNotFound
+	}
+	if wordlist.Builtin {
+		return ErrReadOnly
 	}
-	logging.Info("Added new wordlist: ID=%s Name=%s Words=%d", id, name, len(words))
-	return id
+	if derived := m.derivedFrom(id); len(derived) > 0 {
+		return fmt.Errorf("%w: wordlists %s are derived from it", ErrInUse, strings.Join(derived, ", "))
+	}
+
+	if err := os.Remove(m.wordsPath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logging.Error("Failed to delete wordlist %s: %v", id, err)
+		return fmt.Errorf("failed to delete wordlist: %w", err)
+	}
+	if err := os.Remove(m.metaPath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logging.Error("Failed to delete wordlist metadata %s: %v", id, err)
+	}
+
+	delete(m.lists, id)
+	logging.Info("Deleted wordlist: ID=%s Name=%s", id, wordlist.Name)
+	return nil
+}
+
+// Rename changes a wordlist's display name
+func (m *Manager) Rename(id, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wordlist, exists := m.lists[id]
+	if !exists {
+		return ErrNotFound
+	}
+	if wordlist.Builtin {
+		return ErrReadOnly
+	}
+
+	meta, err := m.readMetadata(id)
+	if err != nil {
+		return err
+	}
+	meta.Name = name
+	meta.keepStats(wordlist)
+	if err := m.saveMetadata(meta); err != nil {
+		logging.Error("Failed to save wordlist metadata %s: %v", id, err)
+		return fmt.Errorf("failed to save wordlist metadata: %w", err)
+	}
+
+	m.lists[id] = meta.wordlist()
+	logging.Info("Renamed wordlist: ID=%s From=%s To=%s", id, wordlist.Name, name)
+	return nil
+}
+
+// Describe replaces a wordlist's description, tags, source and creator.
+// Tags are lower-cased and sorted, and repeats are dropped.
+func (m *Manager) Describe(id string, info types.WordlistInfo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wordlist, exists := m.lists[id]
+	if !exists {
+		return ErrNotFound
+	}
+	if wordlist.Builtin {
+		return ErrReadOnly
+	}
+
+	meta, err := m.readMetadata(id)
+	if err != nil {
+		return err
+	}
+	info.Tags = NormalizeTags(info.Tags)
+	meta.WordlistInfo = info
+	meta.keepStats(wordlist)
+	if err := m.saveMetadata(meta); err != nil {
+		logging.Error("Failed to save wordlist metadata %s: %v", id, err)
+		return fmt.Errorf("failed to save wordlist metadata: %w", err)
+	}
+
+	m.lists[id] = meta.wordlist()
+	logging.Info("Described wordlist: ID=%s Tags=%s", id, strings.Join(info.Tags, ","))
+	return nil
+}
+
+// Replace swaps a wordlist's contents for the words read from r. Iterators
+// already open keep reading the old contents.
+func (m *Manager) Replace(id string, r io.Reader) error {
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+
+	m.mu.RLock()
+	err := m.checkStored(id)
+	m.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	tmp, s, err := m.stageWords(r)
+	if err != nil {
+		logging.Error("Failed to replace wordlist %s: %v", id, err)
+		return fmt.Errorf("failed to save wordlist: %w", err)
+	}
+	meta, err := m.swapWords(id, tmp, s)
+	if err != nil {
+		return err
+	}
+	logging.Info("Replaced wordlist: ID=%s Name=%s Lines=%d Size=%d", id, meta.Name, meta.Lines, meta.Size)
+	return nil
+}
+
+// Append adds words to the end of a wordlist. The file is written in
+// place, so iterators already open read the new words too.
+func (m *Manager) Append(id string, words []string) error {
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+
+	m.mu.RLock()
+	err := m.checkStored(id)
+	m.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(m.wordsPath(id), os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		logging.Error("Failed to open wordlist %s for appending: %v", id, err)
+		return fmt.Errorf("failed to append to wordlist: %w", err)
+	}
+	if err := appendWords(file, words); err != nil {
+		file.Close()
+		logging.Error("Failed to append to wordlist %s: %v", id, err)
+		return fmt.Errorf("failed to append to wordlist: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to append to wordlist: %w", err)
+	}
+
+	s, err := fileStats(m.wordsPath(id))
+	if err != nil {
+		return fmt.Errorf("failed to read wordlist: %w", err)
+	}
+	meta, err := m.updateWords(id, s, nil)
+	if err != nil {
+		return err
+	}
+	logging.Info("Appended to wordlist: ID=%s Added=%d Total=%d", id, len(words), meta.Lines)
+	return nil
+}
+
+// stageWords writes r to a temporary file in the base directory without
+// holding m.mu, returning its path and stats for swapWords
+func (m *Manager) stageWords(r io.Reader) (string, *stats, error) {
+	tmp, err := os.CreateTemp(m.baseDir, ".tmp-*")
+	if err != nil {
+		return "", nil, err
+	}
+	s := newStats()
+	_, err = io.Copy(io.MultiWriter(tmp, s), r)
+	if err == nil && s.longest >= maxWordLength {
+		err = fmt.Errorf("line longer than %d bytes", maxWordLength)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", nil, err
+	}
+	return tmp.Name(), s, nil
+}
+
+// swapWords renames a staged file into place as a list's words and updates
+// its metadata
+func (m *Manager) swapWords(id, tmp string, s *stats) (metadata, error) {
+	defer os.Remove(tmp)
+
+	return m.updateWords(id, s, func() error {
+		if err := os.Rename(tmp, m.wordsPath(id)); err != nil {
+			logging.Error("Failed to save wordlist %s: %v", id, err)
+			return fmt.Errorf("failed to save wordlist: %w", err)
+		}
+		return nil
+	})
+}
+
+// updateWords records the stats of a list whose words were written without
+// holding m.mu, running write first if it isn't nil. The list is checked
+// again, as it may have been deleted while the words were written.
+func (m *Manager) updateWords(id string, s *stats, write func() error) (metadata, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkStored(id); err != nil {
+		return metadata{}, err
+	}
+	meta, err := m.readMetadata(id)
+	if err != nil {
+		return metadata{}, err
+	}
+	if write != nil {
+		if err := write(); err != nil {
+			return metadata{}, err
+		}
+	}
+	meta.setStats(s)
+	if err := m.saveMetadata(meta); err != nil {
+		logging.Error("Failed to save wordlist metadata %s: %v", id, err)
+		return metadata{}, fmt.Errorf("failed to save wordlist metadata: %w", err)
+	}
+
+	m.lists[id] = meta.wordlist()
+	m.refreshDerived(id)
+	return meta, nil
+}
+
+// Iterate opens the wordlist for reading one word at a time. Derived lists
+// apply their rules to the base list as it is read.
+func (m *Manager) Iterate(id string) (Iterator, error) {
+	if IsGenerator(id) {
+		gen, err := ParseGenerator(id)
+		if err != nil {
+			return nil, err
+		}
+		return NewGeneratorIterator(gen), nil
+	}
+	if IsLearned(id) {
+		// Learned lists are small, so a snapshot is taken
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		return NewSliceIterator(m.learnedWords(id)), nil
+	}
+
+	wordlist := m.Get(id)
+	if wordlist == nil {
+		return nil, ErrNotFound
+	}
+	if wordlist.Base != "" {
+		set, err := rules.Parse(wordlist.Rules)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidWordlist, err)
+		}
+		base, err := m.Iterate(wordlist.Base)
+		if err != nil {
+			return nil, err
+		}
+		return WithRules(base, set), nil
+	}
+	if wordlist.Builtin {
+		return openBuiltin(id)
+	}
+
+	it, err := newFileIterator(m.wordsPath(id))
+	if err != nil {
+		logging.Error("Failed to open wordlist %s: %v", id, err)
+		return nil, fmt.Errorf("failed to open wordlist: %w", err)
+	}
+	return it, nil
+}
+
+// Open returns the wordlist's file as stored on disk, for downloads.
+// Derived and builtin lists have no file; read them with Iterate.
+func (m *Manager) Open(id string) (*os.File, error) {
+	m.mu.RLock()
+	err := m.checkStored(id)
+	m.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(m.wordsPath(id))
+}
+
+// checkStored returns an error unless id is a list stored as a file. The
+// caller holds m.mu.
+func (m *Manager) checkStored(id string) error {
+	wordlist, exists := m.lists[id]
+	if !exists {
+		return ErrNotFound
+	}
+	if wordlist.Builtin {
+		return ErrReadOnly
+	}
+	if wordlist.Base != "" {
+		return fmt.Errorf("%w: derived wordlists are generated from their base and can't be changed", ErrInvalidWordlist)
+	}
+	return nil
+}
+
+// derivedFrom returns the sorted IDs of the lists derived from id. The
+// caller holds m.mu.
+func (m *Manager) derivedFrom(id string) []string {
+	var ids []string
+	for _, wl := range m.lists {
+		if wl.Base == id {
+			ids = append(ids, wl.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// refreshDerived recomputes the line counts of the lists derived from id,
+// and of the lists derived from those. The caller holds m.mu.
+func (m *Manager) refreshDerived(id string) {
+	for _, derivedID := range m.derivedFrom(id) {
+		updated := *m.lists[derivedID]
+		updated.Lines = m.linesOf(derivedID)
+		m.lists[derivedID] = &updated
+		m.refreshDerived(derivedID)
+	}
+}
+
+// linesOf returns the number of words id yields, working derived lists out
+// from their base rather than trusting their stored count. The caller holds
+// m.mu.
+func (m *Manager) linesOf(id string) int {
+	if IsGenerator(id) {
+		gen, err := ParseGenerator(id)
+		if err != nil {
+			return 0
+		}
+		return gen.Len()
+	}
+	if IsLearned(id) {
+		return len(m.learned[types.JobType(strings.TrimPrefix(id, LearnedPrefix))])
+	}
+
+	wordlist, exists := m.lists[id]
+	if !exists {
+		return 0
+	}
+	if wordlist.Base == "" {
+		return wordlist.Lines
+	}
+	set, err := rules.Parse(wordlist.Rules)
+	if err != nil {
+		return 0
+	}
+	// Learned bases grow after the list is derived, so cap rather than check
+	lines, _ := Keyspace(m.linesOf(wordlist.Base), set.Len())
+	return lines
 }
 
 func (m *Manager) List() []*types.Wordlist {
-	wordlists := make([]*types.Wordlist, 0, len(m.lists))
+	if m.dirChanged() {
+		m.scan()
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	wordlists := make([]*types.Wordlist, 0, len(m.lists)+len(m.learned))
 	for _, wl := range m.lists {
-		wordlists = append(wordlists, &types.Wordlist{
-			ID:    wl.ID,
-			Name:  wl.Name,
-			Words: wl.Words,
-		})
+		wordlists = append(wordlists, wl)
+	}
+	for jobType := range m.learned {
+		wordlists = append(wordlists, m.learnedWordlist(LearnedID(jobType)))
 	}
 
 	logging.Info("Listed wordlists: Total=%d", len(wordlists))
 	return wordlists
 }
 
+func (m *Manager) wordsPath(id string) string {
+	return filepath.Join(m.baseDir, id+wordsExt)
+}
+
+func (m *Manager) metaPath(id string) string {
+	return filepath.Join(m.baseDir, id+metaExt)
+}
+
+// readMetadata returns the stored metadata, or defaults for lists that were
+// dropped into the directory without any
+func (m *Manager) readMetadata(id string) (metadata, error) {
+	meta := metadata{ID: id, Name: id + wordsExt}
+	data, err := os.ReadFile(m.metaPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return meta, nil
+	}
+	if err != nil {
+		return meta, err
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return meta, fmt.Errorf("invalid metadata: %w", err)
+	}
+	return meta, nil
+}
+
+func (m *Manager) saveMetadata(meta metadata) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(m.metaPath(meta.ID), func(f *os.File) error {
+		_, err := f.Write(data)
+		return err
+	})
+}
+
+// scan loads any wordlist in the base directory that isn't known yet. Files
+// without metadata use their file name as both ID and name, and metadata
+// without a words file describes a derived list.
+func (m *Manager) scan() {
+	// Taken before reading, so files added meanwhile trigger another scan
+	if info, err := os.Stat(m.baseDir); err == nil {
+		m.mu.Lock()
+		m.scannedAt = info.ModTime()
+		m.mu.Unlock()
+	}
+	entries, err := os.ReadDir(m.baseDir)
+	if err != nil {
+		logging.Error("Failed to read wordlist directory %s: %v", m.baseDir, err)
+		return
+	}
+
+	files := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			files[entry.Name()] = true
+		}
+	}
+
+	loadedDerived := false
+	for name := range files {
+		var id string
+		switch {
+		case strings.HasSuffix(name, wordsExt):
+			id = strings.TrimSuffix(name, wordsExt)
+		case strings.HasSuffix(name, metaExt) && !files[strings.TrimSuffix(name, metaExt)+wordsExt]:
+			id = strings.TrimSuffix(name, metaExt)
+		default:
+			continue
+		}
+
+		m.mu.RLock()
+		_, known := m.lists[id]
+		m.mu.RUnlock()
+		if known {
+			continue
+		}
+
+		wordlist, err := m.load(id)
+		if err != nil {
+			logging.Error("Failed to load wordlist %s: %v", name, err)
+			continue
+		}
+
+		m.mu.Lock()
+		if _, known := m.lists[id]; !known {
+			m.lists[id] = wordlist
+			loadedDerived = loadedDerived || wordlist.Base != ""
+			logging.Info("Loaded wordlist: ID=%s Name=%s Lines=%d", id, wordlist.Name, wordlist.Lines)
+		}
+		m.mu.Unlock()
+	}
+
+	// Derived lists count their lines from their base, which may have been
+	// loaded after them
+	if loadedDerived {
+		m.mu.Lock()
+		for id, wl := range m.lists {
+			if wl.Base != "" {
+				updated := *wl
+				updated.Lines = m.linesOf(id)
+				m.lists[id] = &updated
+			}
+		}
+		m.mu.Unlock()
+	}
+}
+
+// dirChanged reports whether the base directory was modified since it was
+// last scanned
+func (m *Manager) dirChanged() bool {
+	info, err := os.Stat(m.baseDir)
+	if err != nil {
+		return true
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return !info.ModTime().Equal(m.scannedAt)
+}
+
+// load reads a wordlist's metadata from disk. The file is only read through
+// to count its lines when the metadata doesn't describe it, as for lists
+// dropped into the directory or edited behind the manager's back.
+func (m *Manager) load(id string) (*types.Wordlist, error) {
+	meta, err := m.readMetadata(id)
+	if err != nil {
+		return nil, err
+	}
+	if meta.Base != "" {
+		return meta.wordlist(), nil
+	}
+
+	info, err := os.Stat(m.wordsPath(id))
+	if err != nil {
+		return nil, err
+	}
+	// Lists saved before the average word length was recorded are read
+	// through once to work it out
+	if meta.Checksum == "" || meta.Size != info.Size() || (meta.AvgWordLength == 0 && meta.Lines > 0) {
+		s, err := fileStats(m.wordsPath(id))
+		if err != nil {
+			return nil, err
+		}
+		meta.setStats(s)
+	}
+
+	return meta.wordlist(), nil
+}
+
+// writeWordlist streams r to path, returning the stats of what was written
+func writeWordlist(path string, r io.Reader) (*stats, error) {
+	s := newStats()
+	err := writeFileAtomic(path, func(f *os.File) error {
+		if _, err := io.Copy(io.MultiWriter(f, s), r); err != nil {
+			return err
+		}
+		if s.longest >= maxWordLength {
+			return fmt.Errorf("line longer than %d bytes", maxWordLength)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// appendWords writes one word per line to the end of file, first ending the
+// file's last line if it has no trailing newline
+func appendWords(file *os.File, words []string) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	if info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, info.Size()-1); err != nil {
+			return err
+		}
+		if last[0] != '\n' {
+			w.WriteByte('\n')
+		}
+	}
+	for _, word := range words {
+		w.WriteString(word)
+		w.WriteByte('\n')
+	}
+	return w.Flush()
+}
+
+// writeFileAtomic writes to a temporary file and renames it into place so a
+// crash never leaves a half-written wordlist behind
+func writeFileAtomic(path string, write func(f *os.File) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
 func NewManager(baseDir string) (*Manager, error) {
 	// Ensure the base directory exists
 	if err := os.MkdirAll(baseDir, 0755); err != nil {
@@ -77,9 +808,18 @@ func NewManager(baseDir string) (*Manager, error) {
 
 	logging.Info("Initializing wordlist manager with base directory: %s", baseDir)
 
-	// Initialize and return the Manager
-	return &Manager{
+	// Initialize the Manager and load the wordlists saved by earlier runs
+	m := &Manager{
 		baseDir: baseDir,
 		lists:   make(map[string]*types.Wordlist),
-	}, nil
+		learned: make(map[types.JobType]map[string]int),
+		closing: make(chan struct{}),
+		flushed: make(chan struct{}),
+	}
+	if err := m.loadLearned(); err != nil {
+		logging.Error("Failed to load learned words: %v", err)
+	}
+	m.scan()
+	go m.flushLearned()
+	return m, nil
 }
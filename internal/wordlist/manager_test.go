@@ -1,29 +1,407 @@
 package wordlist
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"fuzzer/types"
 
 	"github.com/stretchr/testify/assert"
 )
 
+// words reads a wordlist back through its iterator
+func words(t *testing.T, m *Manager, id string) []string {
+	it, err := m.Iterate(id)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	defer it.Close()
+
+	var result []string
+	for it.Next() {
+		result = append(result, it.Word())
+	}
+	assert.NoError(t, it.Err())
+	return result
+}
+
 func TestWordlistManager(t *testing.T) {
-	manager, err := NewManager("./test_wordlists")
+	manager, err := NewManager(t.TempDir())
 	assert.NoError(t, err)
 
 	// Test adding a wordlist
-	words := []string{"test1", "test2", "test3"}
-	id := manager.Add("test-list", words)
+	content := "test1\ntest2\ntest3\n"
+	id, err := manager.Add("test-list", strings.NewReader(content), types.WordlistInfo{})
+	assert.NoError(t, err)
 
 	assert.NotEmpty(t, id)
 
-	// Test getting the wordlist
+	// Test getting the wordlist, which only describes the words
 	list := manager.Get(id)
 	assert.NotNil(t, list)
 	assert.Equal(t, "test-list", list.Name)
-	assert.Equal(t, words, list.Words)
+	assert.Equal(t, 3, list.Lines)
+	assert.Equal(t, int64(len(content)), list.Size)
+	sum := sha256.Sum256([]byte(content))
+	assert.Equal(t, hex.EncodeToString(sum[:]), list.Checksum)
+
+	// The words are read through the iterator
+	assert.Equal(t, []string{"test1", "test2", "test3"}, words(t, manager, id))
+
+	_, err = manager.Iterate("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
 
 	// Test listing wordlists
 	lists := manager.List()
 	assert.Len(t, lists, 1)
 	assert.Equal(t, id, lists[0].ID)
 }
+
+func TestWordlistPersistence(t *testing.T) {
+	dir := t.TempDir()
+
+	manager, err := NewManager(dir)
+	assert.NoError(t, err)
+	id, err := manager.Add("dirs", strings.NewReader("admin\nbackup\n"), types.WordlistInfo{})
+	assert.NoError(t, err)
+
+	// A new manager on the same directory sees the saved list
+	reloaded, err := NewManager(dir)
+	assert.NoError(t, err)
+	list := reloaded.Get(id)
+	assert.NotNil(t, list)
+	assert.Equal(t, "dirs", list.Name)
+	assert.Equal(t, manager.Get(id), list)
+	assert.Equal(t, []string{"admin", "backup"}, words(t, reloaded, id))
+
+	// Plain text files dropped into the directory are picked up too, and
+	// a final line without a newline still counts
+	err = os.WriteFile(filepath.Join(dir, "common.txt"), []byte("login\r\nupload"), 0644)
+	assert.NoError(t, err)
+	assert.Len(t, reloaded.List(), 2)
+
+	dropped := reloaded.Get("common")
+	assert.NotNil(t, dropped)
+	assert.Equal(t, "common.txt", dropped.Name)
+	assert.Equal(t, 2, dropped.Lines)
+	assert.Equal(t, []string{"login", "upload"}, words(t, reloaded, "common"))
+
+	// Unknown IDs and listing only rescan once the directory changes
+	info, err := os.Stat(dir)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(dir, "hidden.txt"), []byte("x\n"), 0644)
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chtimes(dir, info.ModTime(), info.ModTime()))
+	assert.Nil(t, reloaded.Get("hidden"))
+	assert.Len(t, reloaded.List(), 2)
+	changed := info.ModTime().Add(time.Second)
+	assert.NoError(t, os.Chtimes(dir, changed, changed))
+	assert.Len(t, reloaded.List(), 3)
+	assert.NotNil(t, reloaded.Get("hidden"))
+
+	// Appending ends the unterminated last line first, and iterators
+	// already open read the new words
+	it, err := reloaded.Iterate("common")
+	assert.NoError(t, err)
+	defer it.Close()
+	assert.NoError(t, reloaded.Append("common", []string{"api"}))
+	assert.Equal(t, 3, reloaded.Get("common").Lines)
+	assert.Equal(t, []string{"login", "upload", "api"}, words(t, reloaded, "common"))
+	var read []string
+	for it.Next() {
+		read = append(read, it.Word())
+	}
+	assert.Equal(t, []string{"login", "upload", "api"}, read)
+}
+
+func TestReplaceDoesNotBlockReads(t *testing.T) {
+	manager, err := NewManager(t.TempDir())
+	assert.NoError(t, err)
+	id, err := manager.Add("dirs", strings.NewReader("admin\n"), types.WordlistInfo{})
+	assert.NoError(t, err)
+
+	// A slow upload leaves the list readable until it is swapped in
+	pr, pw := io.Pipe()
+	done := make(chan error)
+	go func() { done <- manager.Replace(id, pr) }()
+	pw.Write([]byte("login\n"))
+
+	read := make(chan []string)
+	go func() { read <- words(t, manager, id) }()
+	select {
+	case got := <-read:
+		assert.Equal(t, []string{"admin"}, got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("reading blocked on the upload")
+	}
+
+	pw.Write([]byte("upload\n"))
+	pw.Close()
+	assert.NoError(t, <-done)
+	assert.Equal(t, []string{"login", "upload"}, words(t, manager, id))
+	assert.Equal(t, 2, manager.Get(id).Lines)
+}
+
+func TestWordlistLifecycle(t *testing.T) {
+	dir := t.TempDir()
+	manager, err := NewManager(dir)
+	assert.NoError(t, err)
+
+	id, err := manager.Add("dirs", strings.NewReader("admin\n"), types.WordlistInfo{})
+	assert.NoError(t, err)
+	before := manager.Get(id)
+
+	// An iterator opened before a replace keeps reading the old contents
+	it, err := manager.Iterate(id)
+	assert.NoError(t, err)
+	defer it.Close()
+
+	assert.NoError(t, manager.Append(id, []string{"backup", "login"}))
+	assert.NoError(t, manager.Rename(id, "directories"))
+
+	// Earlier results are never changed under their holder
+	assert.Equal(t, 1, before.Lines)
+	assert.Equal(t, "dirs", before.Name)
+
+	reloaded, err := NewManager(dir)
+	assert.NoError(t, err)
+	list := reloaded.Get(id)
+	assert.Equal(t, "directories", list.Name)
+	assert.Equal(t, 3, list.Lines)
+	assert.Equal(t, []string{"admin", "backup", "login"}, words(t, reloaded, id))
+
+	assert.NoError(t, reloaded.Replace(id, strings.NewReader("api\n")))
+	assert.Equal(t, 1, reloaded.Get(id).Lines)
+	assert.True(t, it.Next())
+	assert.Equal(t, "admin", it.Word())
+	file, err := reloaded.Open(id)
+	assert.NoError(t, err)
+	data, err := io.ReadAll(file)
+	file.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, "api\n", string(data))
+
+	assert.NoError(t, reloaded.Delete(id))
+	assert.Nil(t, reloaded.Get(id))
+	assert.ErrorIs(t, reloaded.Delete(id), ErrNotFound)
+	assert.ErrorIs(t, reloaded.Rename(id, "x"), ErrNotFound)
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestDerivedWordlist(t *testing.T) {
+	dir := t.TempDir()
+	manager, err := NewManager(dir)
+	assert.NoError(t, err)
+
+	base, err := manager.Add("users", strings.NewReader("admin\nroot\n"), types.WordlistInfo{})
+	assert.NoError(t, err)
+	id, err := manager.Derive("users-variants", base, []string{"original", "capitalize", "numbers:1-2"})
+	assert.NoError(t, err)
+
+	list := manager.Get(id)
+	assert.Equal(t, base, list.Base)
+	assert.Equal(t, 8, list.Lines)
+	assert.Equal(t, []string{
+		"admin", "Admin", "admin1", "admin2",
+		"root", "Root", "root1", "root2",
+	}, words(t, manager, id))
+
+	// Only the metadata is stored, and it survives a restart
+	_, err = os.Stat(filepath.Join(dir, id+wordsExt))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	reloaded, err := NewManager(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, list, reloaded.Get(id))
+
+	// Growing the base grows the derived list
+	assert.NoError(t, reloaded.Append(base, []string{"guest"}))
+	assert.Equal(t, 12, reloaded.Get(id).Lines)
+
+	// Derived lists can't be written to, and their base can't be deleted
+	assert.ErrorIs(t, reloaded.Append(id, []string{"x"}), ErrInvalidWordlist)
+	_, err = reloaded.Open(id)
+	assert.ErrorIs(t, err, ErrInvalidWordlist)
+	assert.ErrorIs(t, reloaded.Delete(base), ErrInUse)
+
+	_, err = reloaded.Derive("bad", base, []string{"shout"})
+	assert.ErrorIs(t, err, ErrInvalidWordlist)
+	_, err = reloaded.Derive("orphan", "missing", []string{"upper"})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, reloaded.Delete(id))
+	assert.NoError(t, reloaded.Delete(base))
+}
+
+func TestBuiltinWordlists(t *testing.T) {
+	dir := t.TempDir()
+	manager, err := NewManager(dir)
+	assert.NoError(t, err)
+	assert.Nil(t, manager.Get("builtin:directories"))
+	assert.NoError(t, manager.RegisterBuiltins())
+	assert.Len(t, manager.List(), len(builtins))
+
+	list := manager.Get("builtin:directories")
+	if assert.NotNil(t, list) {
+		assert.True(t, list.Builtin)
+		assert.Equal(t, "Common directories", list.Name)
+		assert.NotEmpty(t, list.Checksum)
+		dirs := words(t, manager, list.ID)
+		assert.Len(t, dirs, list.Lines)
+		assert.Contains(t, dirs, "admin")
+	}
+
+	// Every shipped list is clean: no blanks, comments or duplicates
+	for _, b := range builtins {
+		seen := make(map[string]bool)
+		for _, w := range words(t, manager, b.id) {
+			assert.NotEmpty(t, w, b.id)
+			assert.False(t, strings.HasPrefix(w, "#"), b.id)
+			assert.False(t, seen[w], "%s repeats %q", b.id, w)
+			seen[w] = true
+		}
+	}
+
+	// They can be derived from, but not changed
+	id, err := manager.Derive("admin-panels", "builtin:directories", []string{"suffix:.php"})
+	assert.NoError(t, err)
+	assert.Equal(t, list.Lines, manager.Get(id).Lines)
+	assert.ErrorIs(t, manager.Delete("builtin:directories"), ErrReadOnly)
+	assert.ErrorIs(t, manager.Rename("builtin:directories", "mine"), ErrReadOnly)
+	assert.ErrorIs(t, manager.Replace("builtin:directories", strings.NewReader("x\n")), ErrReadOnly)
+	assert.ErrorIs(t, manager.Append("builtin:directories", []string{"x"}), ErrReadOnly)
+	_, err = manager.Open("builtin:directories")
+	assert.ErrorIs(t, err, ErrReadOnly)
+
+	// A restart registers them again under the same IDs, and lists derived
+	// from them count their words once they are
+	reloaded, err := NewManager(dir)
+	assert.NoError(t, err)
+	assert.NoError(t, reloaded.RegisterBuiltins())
+	assert.Equal(t, list, reloaded.Get("builtin:directories"))
+	assert.Equal(t, list.Lines, reloaded.Get(id).Lines)
+}
+
+func TestDescribeAndFilter(t *testing.T) {
+	dir := t.TempDir()
+	manager, err := NewManager(dir)
+	assert.NoError(t, err)
+	assert.NoError(t, manager.RegisterBuiltins())
+
+	// Info given when the list is added is saved with it
+	id, err := manager.Add("api routes", strings.NewReader("users\r\nv1\norders\n"), types.WordlistInfo{Tags: []string{"API"}, CreatedBy: "bob"})
+	assert.NoError(t, err)
+	assert.Equal(t, 4.33, manager.Get(id).AvgWordLength)
+	assert.Equal(t, []string{"api"}, manager.Get(id).Tags)
+	assert.Equal(t, "bob", manager.Get(id).CreatedBy)
+
+	err = manager.Describe(id, types.WordlistInfo{
+		Description: "Routes seen in the billing API",
+		Tags:        []string{" API", "billing", "api", ""},
+		Source:      "https://billing.example.com/openapi.json",
+		CreatedBy:   "alice",
+	})
+	assert.NoError(t, err)
+	list := manager.Get(id)
+	assert.Equal(t, []string{"api", "billing"}, list.Tags)
+	assert.Equal(t, "alice", list.CreatedBy)
+	assert.Equal(t, 3, list.Lines)
+
+	// The description survives a rename and a restart
+	assert.NoError(t, manager.Rename(id, "billing routes"))
+	reloaded, err := NewManager(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, manager.Get(id), reloaded.Get(id))
+
+	assert.ErrorIs(t, manager.Describe("builtin:files", types.WordlistInfo{}), ErrReadOnly)
+	assert.ErrorIs(t, manager.Describe("missing", types.WordlistInfo{}), ErrNotFound)
+
+	ids := func(lists []*types.Wordlist) []string {
+		var result []string
+		for _, wl := range lists {
+			result = append(result, wl.ID)
+		}
+		return result
+	}
+	all := manager.List()
+	assert.Equal(t, []string{id}, ids(Filter(all, "BILLING", nil)))
+	assert.Equal(t, []string{id}, ids(Filter(all, "openapi", []string{"Api"})))
+	assert.Empty(t, Filter(all, "", []string{"api", "dns"}))
+	assert.ElementsMatch(t, []string{"builtin:files", "builtin:backups"}, ids(Filter(all, "", []string{"sensitive"})))
+	assert.Len(t, Filter(all, "", nil), len(all))
+}
+
+func TestLearnedWordlists(t *testing.T) {
+	dir := t.TempDir()
+	manager, err := NewManager(dir)
+	assert.NoError(t, err)
+	id, err := manager.Add("dirs", strings.NewReader("css\nadmin\njs\nbackup\nadmin\nlogin\n"), types.WordlistInfo{})
+	assert.NoError(t, err)
+
+	// Nothing learned yet leaves the order alone
+	assert.Equal(t, words(t, manager, id), ranked(t, manager, id, types.DirectoryType))
+
+	for _, w := range []string{"login", "admin", "admin", "secret"} {
+		assert.NoError(t, manager.Learn(types.DirectoryType, w))
+	}
+	assert.NoError(t, manager.Learn(types.SubdomainType, "www"))
+
+	learned := manager.Get("learned:directory")
+	if assert.NotNil(t, learned) {
+		assert.Equal(t, 3, learned.Lines)
+	}
+	assert.Equal(t, []string{"admin", "login", "secret"}, words(t, manager, "learned:directory"))
+	assert.Len(t, manager.List(), 3)
+
+	// Learned words present in the list move to the front, most hits
+	// first, keeping their repeats; words only learned elsewhere don't
+	// appear
+	assert.Equal(t, []string{"admin", "admin", "login", "css", "js", "backup"}, ranked(t, manager, id, types.DirectoryType))
+	assert.Equal(t, []string{"css", "admin", "js", "backup", "admin", "login"}, ranked(t, manager, id, types.SubdomainType))
+
+	// Learned lists can be derived from, and survive a restart
+	derived, err := manager.Derive("learned-bak", "learned:directory", []string{"suffix:.bak"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, manager.Get(derived).Lines)
+	// Counts are saved on Close rather than on every hit
+	assert.NoFileExists(t, filepath.Join(dir, learnedFile))
+	assert.NoError(t, manager.Close())
+	reloaded, err := NewManager(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"admin", "login", "secret"}, words(t, reloaded, "learned:directory"))
+	assert.Equal(t, []string{"www"}, words(t, reloaded, "learned:subdomain"))
+	assert.Equal(t, 0, reloaded.Get("learned:crawl").Lines)
+
+	// Generated lists, and lists derived from them, are too long to rank
+	_, err = manager.IterateRanked("gen:range:1-100", types.DirectoryType)
+	assert.ErrorIs(t, err, ErrInvalidWordlist)
+	genDerived, err := manager.Derive("gen-bak", "gen:range:1-100", []string{"suffix:.bak"})
+	assert.NoError(t, err)
+	_, err = manager.IterateRanked(genDerived, types.DirectoryType)
+	assert.ErrorIs(t, err, ErrInvalidWordlist)
+	assert.True(t, manager.Generated(genDerived))
+	assert.False(t, manager.Generated(derived))
+}
+
+// ranked reads a wordlist back through IterateRanked
+func ranked(t *testing.T, m *Manager, id string, jobType types.JobType) []string {
+	it, err := m.IterateRanked(id, jobType)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	defer it.Close()
+	var result []string
+	for it.Next() {
+		result = append(result, it.Word())
+	}
+	assert.NoError(t, it.Err())
+	return result
+}
@@ -0,0 +1,306 @@
+package wordlist
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encodings accepted by NormalizeOptions
+const (
+	// EncodingUTF8 rejects uploads containing invalid UTF-8
+	EncodingUTF8 = "utf-8"
+	// EncodingLatin1 converts uploads from ISO-8859-1 to UTF-8
+	EncodingLatin1 = "latin1"
+	// EncodingRaw stores lines byte for byte, for payload lists that are
+	// deliberately not valid text
+	EncodingRaw = "raw"
+)
+
+// ErrInvalidWordlist is wrapped by errors caused by the content of an upload
+// rather than by storing it
+var ErrInvalidWordlist = errors.New("invalid wordlist")
+
+// NormalizeOptions controls how an uploaded wordlist is cleaned up before it
+// is stored. CRLF line endings and a leading byte order mark are always
+// removed, and UTF-16 uploads are converted to UTF-8.
+type NormalizeOptions struct {
+	// Trim removes leading and trailing whitespace from every line
+	Trim bool `json:"trim"`
+	// DropComments drops lines starting with #
+	DropComments bool `json:"dropComments"`
+	// DropBlank drops empty lines
+	DropBlank bool `json:"dropBlank"`
+	// Dedupe drops lines already seen earlier in the upload
+	Dedupe bool `json:"dedupe"`
+	// Encoding is one of EncodingUTF8, EncodingLatin1 or EncodingRaw
+	Encoding string `json:"encoding"`
+}
+
+// DefaultNormalizeOptions enables every clean-up step and requires UTF-8
+func DefaultNormalizeOptions() NormalizeOptions {
+	return NormalizeOptions{
+		Trim:         true,
+		DropComments: true,
+		DropBlank:    true,
+		Dedupe:       true,
+		Encoding:     EncodingUTF8,
+	}
+}
+
+// NormalizeStats counts what happened to the lines of an upload
+type NormalizeStats struct {
+	Read       int  `json:"read"`
+	Kept       int  `json:"kept"`
+	Blank      int  `json:"blank"`
+	Comments   int  `json:"comments"`
+	Duplicates int  `json:"duplicates"`
+	TooLong    int  `json:"tooLong"`
+	Converted  int  `json:"converted"`
+	Gzip       bool `json:"gzip"`
+	UTF16      bool `json:"utf16"`
+}
+
+// Dropped is the number of lines that were read but not kept
+func (s NormalizeStats) Dropped() int {
+	return s.Read - s.Kept
+}
+
+// Normalizer is an io.Reader that yields the normalized lines of an upload,
+// one per line, so it can be streamed straight into Manager.Add. Gzip
+// compressed uploads are detected and decompressed.
+type Normalizer struct {
+	r     *bufio.Reader
+	opts  NormalizeOptions
+	stats NormalizeStats
+	// seen holds 64-bit hashes rather than the lines themselves so that
+	// deduplicating huge lists stays affordable
+	seen map[uint64]struct{}
+	line []byte
+	buf  []byte
+	out  []byte
+	err  error
+}
+
+func NewNormalizer(r io.Reader, opts NormalizeOptions) (*Normalizer, error) {
+	switch opts.Encoding {
+	case "":
+		opts.Encoding = EncodingUTF8
+	case EncodingUTF8, EncodingLatin1, EncodingRaw:
+	default:
+		return nil, fmt.Errorf("unknown encoding %q (use %s, %s or %s)",
+			opts.Encoding, EncodingUTF8, EncodingLatin1, EncodingRaw)
+	}
+
+	n := &Normalizer{
+		r:    bufio.NewReaderSize(r, 64*1024),
+		opts: opts,
+		seen: make(map[uint64]struct{}),
+	}
+
+	if magic, _ := n.r.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(n.r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidWordlist, err)
+		}
+		n.r = bufio.NewReaderSize(gz, 64*1024)
+		n.stats.Gzip = true
+	}
+
+	bom, _ := n.r.Peek(3)
+	switch {
+	case bytes.HasPrefix(bom, []byte{0xef, 0xbb, 0xbf}):
+		n.r.Discard(3)
+	case bytes.HasPrefix(bom, []byte{0xff, 0xfe}):
+		n.r.Discard(2)
+		n.r = bufio.NewReaderSize(&utf16Reader{r: n.r, order: binary.LittleEndian}, 64*1024)
+		n.stats.UTF16 = true
+	case bytes.HasPrefix(bom, []byte{0xfe, 0xff}):
+		n.r.Discard(2)
+		n.r = bufio.NewReaderSize(&utf16Reader{r: n.r, order: binary.BigEndian}, 64*1024)
+		n.stats.UTF16 = true
+	}
+	// Decoded UTF-16 is already UTF-8
+	if n.stats.UTF16 {
+		n.opts.Encoding = EncodingUTF8
+	}
+
+	return n, nil
+}
+
+// Stats returns the counts so far; they are final once Read returns io.EOF
+func (n *Normalizer) Stats() NormalizeStats {
+	return n.stats
+}
+
+func (n *Normalizer) Read(p []byte) (int, error) {
+	for len(n.out) == 0 {
+		if n.err != nil {
+			return 0, n.err
+		}
+		n.next()
+	}
+	c := copy(p, n.out)
+	n.out = n.out[c:]
+	return c, nil
+}
+
+// next processes one input line, leaving it in out if it is kept
+func (n *Normalizer) next() {
+	line, tooLong, err := n.readLine()
+	if err != nil {
+		if err != io.EOF {
+			err = fmt.Errorf("%w: %v", ErrInvalidWordlist, err)
+		}
+		n.err = err
+		return
+	}
+	n.stats.Read++
+
+	if tooLong {
+		n.stats.TooLong++
+		return
+	}
+
+	switch n.opts.Encoding {
+	case EncodingUTF8:
+		if !utf8.Valid(line) {
+			n.err = fmt.Errorf("%w: line %d is not valid UTF-8 (upload with encoding %s or %s)",
+				ErrInvalidWordlist, n.stats.Read, EncodingLatin1, EncodingRaw)
+			return
+		}
+	case EncodingLatin1:
+		if converted, ok := latin1ToUTF8(line); ok {
+			line = converted
+			n.stats.Converted++
+		}
+	}
+
+	if n.opts.Trim {
+		line = bytes.TrimSpace(line)
+	}
+	if len(line) == 0 && n.opts.DropBlank {
+		n.stats.Blank++
+		return
+	}
+	if n.opts.DropComments && len(line) > 0 && line[0] == '#' {
+		n.stats.Comments++
+		return
+	}
+	if n.opts.Dedupe {
+		h := fnv.New64a()
+		h.Write(line)
+		sum := h.Sum64()
+		if _, dup := n.seen[sum]; dup {
+			n.stats.Duplicates++
+			return
+		}
+		n.seen[sum] = struct{}{}
+	}
+
+	n.stats.Kept++
+	n.buf = append(append(n.buf[:0], line...), '\n')
+	n.out = n.buf
+}
+
+// readLine returns the next line without its line ending. Lines too long to
+// be stored are read through and reported rather than returned.
+func (n *Normalizer) readLine() ([]byte, bool, error) {
+	n.line = n.line[:0]
+	tooLong := false
+	for {
+		chunk, err := n.r.ReadSlice('\n')
+		if len(n.line)+len(chunk) > maxWordLength {
+			tooLong = true
+		} else if !tooLong {
+			n.line = append(n.line, chunk...)
+		}
+
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF && (len(n.line) > 0 || tooLong) {
+			err = nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		line := bytes.TrimSuffix(n.line, []byte("\n"))
+		return bytes.TrimSuffix(line, []byte("\r")), tooLong, nil
+	}
+}
+
+// latin1ToUTF8 converts an ISO-8859-1 line, reporting whether it contained
+// anything besides ASCII
+func latin1ToUTF8(line []byte) ([]byte, bool) {
+	ascii := true
+	for _, b := range line {
+		if b >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return line, false
+	}
+
+	converted := make([]byte, 0, len(line)*2)
+	for _, b := range line {
+		converted = utf8.AppendRune(converted, rune(b))
+	}
+	return converted, true
+}
+
+// utf16Reader decodes UTF-16 into UTF-8
+type utf16Reader struct {
+	r     io.Reader
+	order binary.ByteOrder
+	buf   []byte
+}
+
+func (u *utf16Reader) Read(p []byte) (int, error) {
+	for len(u.buf) == 0 {
+		r, err := u.readRune()
+		if err != nil {
+			return 0, err
+		}
+		u.buf = utf8.AppendRune(u.buf[:0], r)
+	}
+	c := copy(p, u.buf)
+	u.buf = u.buf[c:]
+	return c, nil
+}
+
+func (u *utf16Reader) readRune() (rune, error) {
+	first, err := u.readUnit()
+	if err != nil {
+		return 0, err
+	}
+	if !utf16.IsSurrogate(first) {
+		return first, nil
+	}
+	second, err := u.readUnit()
+	if err != nil {
+		return 0, err
+	}
+	return utf16.DecodeRune(first, second), nil
+}
+
+func (u *utf16Reader) readUnit() (rune, error) {
+	var b [2]byte
+	if _, err := io.ReadFull(u.r, b[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return 0, errors.New("UTF-16 upload has an odd number of bytes")
+		}
+		return 0, err
+	}
+	return rune(u.order.Uint16(b[:])), nil
+}
@@ -0,0 +1,99 @@
+package wordlist
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func normalize(t *testing.T, input []byte, opts NormalizeOptions) (string, NormalizeStats, error) {
+	n, err := NewNormalizer(bytes.NewReader(input), opts)
+	if err != nil {
+		return "", NormalizeStats{}, err
+	}
+	out, err := io.ReadAll(n)
+	return string(out), n.Stats(), err
+}
+
+func TestNormalizer(t *testing.T) {
+	input := "\xef\xbb\xbfadmin\r\n  login \r\n\r\n# comment\r\nadmin\r\nbackup"
+
+	out, stats, err := normalize(t, []byte(input), DefaultNormalizeOptions())
+	assert.NoError(t, err)
+	assert.Equal(t, "admin\nlogin\nbackup\n", out)
+	assert.Equal(t, NormalizeStats{Read: 6, Kept: 3, Blank: 1, Comments: 1, Duplicates: 1}, stats)
+	assert.Equal(t, 3, stats.Dropped())
+
+	// With every step off only the BOM and line endings are touched
+	out, stats, err = normalize(t, []byte(input), NormalizeOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "admin\n  login \n\n# comment\nadmin\nbackup\n", out)
+	assert.Equal(t, 6, stats.Kept)
+}
+
+func TestNormalizerGzip(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte("admin\nlogin\n"))
+	gz.Close()
+
+	out, stats, err := normalize(t, buf.Bytes(), DefaultNormalizeOptions())
+	assert.NoError(t, err)
+	assert.Equal(t, "admin\nlogin\n", out)
+	assert.True(t, stats.Gzip)
+
+	// A corrupt stream is the upload's fault
+	_, _, err = normalize(t, buf.Bytes()[:buf.Len()-4], DefaultNormalizeOptions())
+	assert.ErrorIs(t, err, ErrInvalidWordlist)
+}
+
+func TestNormalizerEncoding(t *testing.T) {
+	latin1 := []byte("caf\xe9\nadmin\n")
+
+	_, _, err := normalize(t, latin1, DefaultNormalizeOptions())
+	assert.ErrorIs(t, err, ErrInvalidWordlist)
+	assert.ErrorContains(t, err, "line 1")
+
+	opts := DefaultNormalizeOptions()
+	opts.Encoding = EncodingLatin1
+	out, stats, err := normalize(t, latin1, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "café\nadmin\n", out)
+	assert.Equal(t, 1, stats.Converted)
+
+	opts.Encoding = EncodingRaw
+	out, _, err = normalize(t, latin1, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, string(latin1), out)
+
+	opts.Encoding = "ebcdic"
+	_, _, err = normalize(t, latin1, opts)
+	assert.Error(t, err)
+
+	// UTF-16 with a byte order mark is converted
+	utf16le := []byte{0xff, 0xfe, 'o', 0, 'k', 0, '\n', 0, 0x3d, 0xd8, 0x00, 0xde, '\n', 0}
+	out, stats, err = normalize(t, utf16le, DefaultNormalizeOptions())
+	assert.NoError(t, err)
+	assert.Equal(t, "ok\n😀\n", out)
+	assert.True(t, stats.UTF16)
+}
+
+func TestNormalizerLongLines(t *testing.T) {
+	long := strings.Repeat("a", maxWordLength+10)
+	input := "admin\n" + long + "\nlogin\n"
+
+	out, stats, err := normalize(t, []byte(input), DefaultNormalizeOptions())
+	assert.NoError(t, err)
+	assert.Equal(t, "admin\nlogin\n", out)
+	assert.Equal(t, 1, stats.TooLong)
+
+	// Lines past the old 64KB scanner limit are kept
+	medium := strings.Repeat("b", 100*1024)
+	out, _, err = normalize(t, []byte(medium+"\n"), DefaultNormalizeOptions())
+	assert.NoError(t, err)
+	assert.Equal(t, medium+"\n", out)
+}
@@ -0,0 +1,57 @@
+package wordlist
+
+import "fuzzer/internal/rules"
+
+// ruleIterator yields every variant of every word of another iterator, one
+// at a time
+type ruleIterator struct {
+	words   Iterator
+	rules   []rules.Rule
+	word    string
+	hasWord bool
+	rule    int
+	variant int
+	current string
+}
+
+// WithRules applies a rule set lazily to the words of it. The result yields
+// set.Len() variants for each word.
+func WithRules(it Iterator, set *rules.Set) Iterator {
+	return &ruleIterator{words: it, rules: set.Rules()}
+}
+
+func (it *ruleIterator) Next() bool {
+	for {
+		if it.hasWord && it.rule < len(it.rules) {
+			r := it.rules[it.rule]
+			if it.variant < r.Len() {
+				it.current = r.Apply(it.word, it.variant)
+				it.variant++
+				return true
+			}
+			it.rule++
+			it.variant = 0
+			continue
+		}
+
+		if !it.words.Next() {
+			return false
+		}
+		it.word = it.words.Word()
+		it.hasWord = true
+		it.rule = 0
+		it.variant = 0
+	}
+}
+
+func (it *ruleIterator) Word() string {
+	return it.current
+}
+
+func (it *ruleIterator) Err() error {
+	return it.words.Err()
+}
+
+func (it *ruleIterator) Close() error {
+	return it.words.Close()
+}
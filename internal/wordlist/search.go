@@ -0,0 +1,60 @@
+package wordlist
+
+import (
+	"slices"
+	"strings"
+
+	"fuzzer/types"
+)
+
+// NormalizeTags trims and lower-cases tags, drops empty ones and repeats,
+// and sorts them
+func NormalizeTags(tags []string) []string {
+	var result []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+// Filter returns the lists that match search and carry every one of tags.
+// search is matched case-insensitively against the ID, name, description,
+// source and tags; an empty search matches every list.
+func Filter(lists []*types.Wordlist, search string, tags []string) []*types.Wordlist {
+	search = strings.ToLower(strings.TrimSpace(search))
+	tags = NormalizeTags(tags)
+
+	var result []*types.Wordlist
+	for _, wl := range lists {
+		if matchesSearch(wl, search) && hasTags(wl, tags) {
+			result = append(result, wl)
+		}
+	}
+	return result
+}
+
+func matchesSearch(wl *types.Wordlist, search string) bool {
+	if search == "" {
+		return true
+	}
+	fields := append([]string{wl.ID, wl.Name, wl.Description, wl.Source}, wl.Tags...)
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), search) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasTags(wl *types.Wordlist, tags []string) bool {
+	for _, tag := range tags {
+		if !slices.Contains(wl.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
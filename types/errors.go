@@ -0,0 +1,10 @@
+package types
+
+import "errors"
+
+var (
+	// ErrShuttingDown is returned when work is submitted after shutdown began
+	ErrShuttingDown = errors.New("server is shutting down")
+	// ErrInvalidJob is wrapped by errors caused by a bad job request
+	ErrInvalidJob = errors.New("invalid job")
+)
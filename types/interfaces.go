@@ -1,8 +1,10 @@
 package types
 
 type FuzzerManager interface {
-	StartJob(target, wordlistID string, jobType JobType) error
+	StartJob(target, wordlistID string, jobType JobType, opts JobOptions) error
 	StopJob(jobID string) error
 	GetJobs() ([]*Job, error)
 	DeleteJob(jobID string) error
+	UpdateRateLimit(newLimit float64)
+	WordlistHits() map[string]int
 }
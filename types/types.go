@@ -10,27 +10,185 @@ type JobType string
 const (
 	DirectoryType JobType = "directory"
 	SubdomainType JobType = "subdomain"
+	// CrawlType jobs build a wordlist from the target's own content rather
+	// than fuzzing it with one
+	CrawlType JobType = "crawl"
+	// VerbType jobs compare the responses of known paths across request
+	// methods and method override headers
+	VerbType JobType = "verb"
+	// ParamType jobs look for hidden parameters of a known endpoint, using
+	// the wordlist as parameter names
+	ParamType JobType = "param"
+	// BackupType jobs look for the backups and sensitive files known paths
+	// left behind
+	BackupType JobType = "backup"
 )
 
 type Job struct {
-	ID         string    `json:"id"`
-	Target     string    `json:"target"`
-	Type       JobType   `json:"type"`
-	WordlistID string    `json:"wordlistId"`
-	Status     string    `json:"status"`
-	Progress   int       `json:"progress"`
-	Findings   []Finding `json:"findings"`
-	StartTime  time.Time `json:"startTime"`
+	ID         string     `json:"id"`
+	Target     string     `json:"target"`
+	Type       JobType    `json:"type"`
+	WordlistID string     `json:"wordlistId"`
+	Status     string     `json:"status"`
+	Progress   int        `json:"progress"`
+	Findings   []Finding  `json:"findings"`
+	StartTime  time.Time  `json:"startTime"`
+	Options    JobOptions `json:"options"`
+}
+
+// JobOptions holds the request settings for a job. Zero values are filled in
+// from the server's configured job defaults when the job is started.
+type JobOptions struct {
+	// Project selects a configured project whose scope the job must obey
+	Project string `json:"project,omitempty"`
+	// Timeout is the per-request timeout in seconds
+	Timeout     int               `json:"timeout,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	MatchStatus []int             `json:"matchStatus,omitempty"`
+	// Rules derive variants from each word of the wordlist; see the rules
+	// package for the syntax
+	Rules []string `json:"rules,omitempty"`
+	// Processors encode each word before it is put into the request; see
+	// the payload package for the names
+	Processors []string `json:"processors,omitempty"`
+	// RankByHits moves the words that produced the most findings in earlier
+	// jobs of the same type to the front of the wordlist
+	RankByHits bool `json:"rankByHits,omitempty"`
+	// Match is an expression deciding which responses are findings,
+	// replacing the job type's own judgement; see the match package for the
+	// syntax
+	Match string `json:"match,omitempty"`
+	// Methods sends every payload with each of these request methods, each
+	// judged on its own. Verb tampering jobs compare them instead.
+	Methods []string `json:"methods,omitempty"`
+	// BypassForbidden tries common access control bypasses on every 403
+	// finding, recording those that get through as findings of their own
+	BypassForbidden bool `json:"bypassForbidden,omitempty"`
+	// Seed sends the paths named by the target's robots.txt, sitemaps and
+	// security.txt before the wordlist, for job types that support it
+	Seed bool `json:"seed,omitempty"`
+	// Spider queues the paths linked from findings' pages and scripts, for
+	// job types that support it
+	Spider *SpiderOptions `json:"spider,omitempty"`
+	// JS scans the scripts among findings for endpoints and secrets,
+	// recording each as a finding of type "js"
+	JS *JSOptions `json:"js,omitempty"`
+	// Crawl configures crawl jobs and is ignored by other types
+	Crawl *CrawlOptions `json:"crawl,omitempty"`
+	// Params configures parameter discovery jobs and is ignored by other
+	// types
+	Params *ParamOptions `json:"params,omitempty"`
+}
+
+// CrawlOptions controls a crawl job. Zero values fall back to the crawl
+// package's defaults.
+type CrawlOptions struct {
+	// MaxDepth is how many links away from the target to follow
+	MaxDepth int `json:"maxDepth,omitempty"`
+	// MaxPages stops the crawl after this many fetches
+	MaxPages int `json:"maxPages,omitempty"`
+	// MinWordLength drops shorter words
+	MinWordLength int `json:"minWordLength,omitempty"`
+	// IncludeSubdomains follows links to subdomains of the target's host
+	IncludeSubdomains bool `json:"includeSubdomains,omitempty"`
+	// WordlistName names the wordlist the words are saved to
+	WordlistName string `json:"wordlistName,omitempty"`
+}
+
+// SpiderOptions controls how far a job follows the links in its findings.
+// Zero values fall back to 2 and 100.
+type SpiderOptions struct {
+	// MaxDepth is how many links away from a wordlist or seed finding to go
+	MaxDepth int `json:"maxDepth,omitempty"`
+	// MaxPages caps how many linked paths are queued
+	MaxPages int `json:"maxPages,omitempty"`
+}
+
+// JSOptions controls what is done with the scripts a job finds
+type JSOptions struct {
+	// FeedPaths queues the endpoints found in scripts as payloads for the
+	// job, for job types that support spidering
+	FeedPaths bool `json:"feedPaths,omitempty"`
+}
+
+// ParamOptions controls a parameter discovery job
+type ParamOptions struct {
+	// Locations are where parameters are sent: "query", "form" and
+	// "json". All three are tried if unset.
+	Locations []string `json:"locations,omitempty"`
+	// BatchSize is how many names are sent in each request, 32 if unset
+	BatchSize int `json:"batchSize,omitempty"`
 }
 
 type Finding struct {
 	URL   string    `json:"url"`
 	Type  string    `json:"type"`
 	Found time.Time `json:"found"`
+	// Payload is the word from the wordlist and Encoded is what was sent
+	// after the job's processors ran
+	Payload string `json:"payload,omitempty"`
+	Encoded string `json:"encoded,omitempty"`
+	// Method is the request method, if the job chose it
+	Method string `json:"method,omitempty"`
+	// Detail explains findings that aren't just a response, such as how a
+	// response differed from another
+	Detail string `json:"detail,omitempty"`
+	// Parent is the URL of the finding this one follows up, such as the
+	// 403 a bypass got past
+	Parent string `json:"parent,omitempty"`
+	// Source says where the payload came from: "bruteforce" for the
+	// wordlist, "seed" or "crawled"
+	Source string `json:"source,omitempty"`
+	// File and Line locate what a finding was extracted from, such as the
+	// script a secret was found in
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
 }
 
+// Finding sources say where a finding's payload came from
+const (
+	// SourceBruteforce is a word from the job's wordlist
+	SourceBruteforce = "bruteforce"
+	// SourceSeed is a path a Seeder found before the wordlist was read
+	SourceSeed = "seed"
+	// SourceCrawled is a link the spider found in an earlier finding
+	SourceCrawled = "crawled"
+)
+
+// Wordlist describes a wordlist stored on disk. The words themselves are
+// never held in memory; they are read through the wordlist manager's
+// iterator.
 type Wordlist struct {
-	ID    string   `json:"id"`
-	Name  string   `json:"name"`
-	Words []string `json:"words"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	WordlistInfo
+	// Lines is the number of words the iterator yields
+	Lines int `json:"lines"`
+	// AvgWordLength is the mean length of the words in bytes. It is only
+	// known for stored lists.
+	AvgWordLength float64 `json:"avgWordLength,omitempty"`
+	// Size is the size of the file in bytes
+	Size int64 `json:"size"`
+	// Checksum is the hex SHA-256 of the file
+	Checksum string `json:"checksum"`
+	// Base and Rules are set for derived wordlists, whose words are
+	// generated from the base list's by the rules rather than stored
+	Base  string   `json:"base,omitempty"`
+	Rules []string `json:"rules,omitempty"`
+	// Builtin lists ship with the server and can't be changed or deleted
+	Builtin bool `json:"builtin,omitempty"`
+	// Hits is the number of findings made with the list across all jobs.
+	// It is worked out by the API when lists are listed.
+	Hits int `json:"hits"`
+}
+
+// WordlistInfo is the descriptive metadata of a wordlist, set by whoever
+// created it rather than worked out from its words
+type WordlistInfo struct {
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	// Source records where the words came from, such as a URL or the
+	// target of the crawl job that built the list
+	Source    string `json:"source,omitempty"`
+	CreatedBy string `json:"createdBy,omitempty"`
 }